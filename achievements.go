@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Achievements. ::: Each achievement is a rule that watches the game's events (see events.go) and says when it has
+// been earned; a new one is a new entry in the achievements table, with no change to the game's handlers. What the
+// rules have counted so far, and what has been unlocked, is saved in the student's profile.
+
+const achievementsFileName = "achievements.json"
+
+// achievementState is everything the rules remember between events, and between sittings.
+type achievementState struct {
+	Unlocked    map[string]time.Time `json:"unlocked"`     // by achievement ID
+	Marked      int                  `json:"marked"`       // notes ever marked
+	CleanNotes  int                  `json:"clean_notes"`  // notes found right since the last round with a mistake
+	LedgerFound map[string]bool      `json:"ledger_found"` // notes off the staff lines found right
+	LastDay     string               `json:"last_day"`     // the latest day a round was played, as 2006-01-02
+	DayStreak   int                  `json:"day_streak"`   // days in a row with a round played, up to LastDay
+	Erased      bool                 `json:"-"`            // a note has been taken back in the round on screen
+}
+
+// achievement is one thing to earn; Rule sees every event and reports true once it is earned.
+type achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Rule        func(e gameEvent, s *achievementState) bool
+}
+
+// offStaffNotes are the notes above and below the five lines of each staff.
+var offStaffNotes = []string{"G5", "A5", "D4", "C4", "B3", "F2"}
+
+// spansBothStaves reports whether the pitches reach both the treble and the bass staff.
+func spansBothStaves(names []string) bool {
+	treble, bass := false, false
+	for _, name := range names {
+		if p, err := parsePitch(name); err == nil {
+			treble = treble || p.step() >= trebleLowStep
+			bass = bass || p.step() < trebleLowStep
+		}
+	}
+	return treble && bass
+}
+
+var achievements = []achievement{
+	{"first-note", "First Note", "Put a note on the staff",
+		func(e gameEvent, s *achievementState) bool {
+			_, marked := e.(NoteMarked)
+			return marked
+		}},
+	{"first-perfect-grand-staff", "First Perfect Grand Staff", "Find every note of a letter on both staves, with no mistakes",
+		func(e gameEvent, s *achievementState) bool {
+			c, ok := e.(RoundChecked)
+			return ok && c.Record.Mode == findTheNote.String() && c.Record.Solved && c.Record.Hints == 0 &&
+				spansBothStaves(c.Record.Targets)
+		}},
+	{"no-eraser", "No Eraser", "Solve a round with more than one note without taking any note back",
+		func(e gameEvent, s *achievementState) bool {
+			switch e := e.(type) {
+			case NoteRemoved:
+				s.Erased = true
+			case RoundChecked:
+				return e.Record.Solved && len(e.Record.Targets) > 1 && !s.Erased
+			case RoundStarted, RoundCompleted:
+				s.Erased = false
+			}
+			return false
+		}},
+	{"hundred-clean-notes", "100 Notes Without Error", "Find 100 notes right without a wrong or missing one in between",
+		func(e gameEvent, s *achievementState) bool {
+			switch e := e.(type) {
+			case RoundChecked: // a Check that finds a mistake ends the run, even if the round is put right after
+				if len(e.Record.Wrong) > 0 || len(e.Record.Missing) > 0 {
+					s.CleanNotes = 0
+				}
+			case RoundCompleted: // only rounds right at the first Check count
+				if e.Record.Hints == 0 && len(e.Record.Wrong) == 0 && len(e.Record.Missing) == 0 {
+					s.CleanNotes += len(e.Record.Correct)
+				} else {
+					s.CleanNotes = 0
+				}
+			}
+			return s.CleanNotes >= 100
+		}},
+	{"all-ledger-notes", "All Ledger Notes", "Find each of the notes off the staff lines: G5, A5, D4, C4, B3 and F2",
+		func(e gameEvent, s *achievementState) bool {
+			c, ok := e.(RoundCompleted)
+			if !ok {
+				return false
+			}
+			if s.LedgerFound == nil {
+				s.LedgerFound = map[string]bool{}
+			}
+			for _, name := range c.Record.Correct {
+				if p, err := parsePitch(name); err == nil {
+					for _, off := range offStaffNotes {
+						s.LedgerFound[off] = s.LedgerFound[off] || p.natural().String() == off
+					}
+				}
+			}
+			for _, off := range offStaffNotes {
+				if !s.LedgerFound[off] {
+					return false
+				}
+			}
+			return true
+		}},
+	{"seven-day-streak", "7-Day Streak", "Play at least one round on seven days in a row",
+		func(e gameEvent, s *achievementState) bool {
+			c, ok := e.(RoundCompleted)
+			if !ok {
+				return false
+			}
+			day := c.Record.Time.Local().Format("2006-01-02")
+			switch {
+			case day == s.LastDay:
+			case s.LastDay != "" && c.Record.Time.Local().AddDate(0, 0, -1).Format("2006-01-02") == s.LastDay:
+				s.DayStreak++
+			default:
+				s.DayStreak = 1
+			}
+			s.LastDay = day
+			return s.DayStreak >= 7
+		}},
+}
+
+// achievementTracker runs the rules over the game's events for one profile.
+type achievementTracker struct {
+	State    achievementState
+	path     string // where the state is saved; empty saves nothing
+	OnUnlock func(achievement)
+}
+
+// load switches the tracker to the state saved at path; a missing file is a student with nothing earned yet.
+func (t *achievementTracker) load(path string) error {
+	t.path, t.State = path, achievementState{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &t.State)
+}
+
+// save writes the state out whole, through a temporary file, so a crash mid-write can't leave half of it.
+func (t *achievementTracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(t.State, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(t.path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(t.path+".tmp", t.path)
+}
+
+// observe gives the event to the rule of each achievement not earned yet, unlocking those that report true. The state
+// is saved when something is unlocked, and at the end of every round; what is counted in between waits for that.
+func (t *achievementTracker) observe(e gameEvent) {
+	if t.State.Unlocked == nil {
+		t.State.Unlocked = map[string]time.Time{}
+	}
+	if _, ok := e.(NoteMarked); ok {
+		t.State.Marked++
+	}
+	_, changed := e.(RoundCompleted)
+	for _, a := range achievements {
+		if _, done := t.State.Unlocked[a.ID]; done || !a.Rule(e, &t.State) {
+			continue
+		}
+		t.State.Unlocked[a.ID] = time.Now()
+		changed = true
+		fmt.Printf("Achievement unlocked: %s\n", a.Name)
+		if t.OnUnlock != nil {
+			t.OnUnlock(a)
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := t.save(); err != nil {
+		fmt.Println("Couldn't save achievements:", err)
+	}
+}
+
+// showToast shows a short message in the top right corner of the window for a few seconds.
+func showToast(c fyne.Canvas, text string) {
+	background := canvas.NewRectangle(color.NRGBA{R: 40, G: 40, B: 40, A: 230})
+	label := canvas.NewText(text, color.White)
+	label.TextStyle = fyne.TextStyle{Bold: true}
+	label.TextSize = 18
+	toast := widget.NewPopUp(container.NewStack(background, container.NewPadded(label)), c)
+	size := toast.MinSize()
+	toast.ShowAtPosition(fyne.NewPos(c.Size().Width-size.Width-20, 20))
+	time.AfterFunc(4*time.Second, toast.Hide) // widgets lock themselves; no game state is touched
+}
+
+// showAchievementsDialog lists every achievement, the earned ones with the date they were earned.
+func showAchievementsDialog(parentWindow fyne.Window, s achievementState) {
+	list := container.NewVBox()
+	for _, a := range achievements {
+		status := "Not yet"
+		if at, ok := s.Unlocked[a.ID]; ok {
+			status = "Earned " + at.Local().Format("2006-01-02")
+		}
+		name := widget.NewLabel(fmt.Sprintf("%s (%s)", a.Name, status))
+		name.TextStyle = fyne.TextStyle{Bold: s.Unlocked[a.ID] != time.Time{}}
+		list.Add(name)
+		list.Add(widget.NewLabel("    " + a.Description))
+	}
+	list.Add(widget.NewLabel(fmt.Sprintf("Notes marked: %d · clean notes in a row: %d · days in a row: %d",
+		s.Marked, s.CleanNotes, s.DayStreak)))
+	dialog.ShowCustom("Achievements", "Close", list, parentWindow)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// cleanRound is a find-the-note round answered right at its first Check.
+func cleanRound(targets ...string) roundRecord {
+	return roundRecord{Mode: findTheNote.String(), Targets: targets, Marks: targets, Correct: targets, Solved: true}
+}
+
+// checkedThenCompleted is the events of a round judged once and then over.
+func checkedThenCompleted(r roundRecord) []gameEvent {
+	r.Time = time.Date(2025, 3, 1, 9, 0, 0, 0, time.Local)
+	return []gameEvent{RoundStarted{Mode: findTheNote}, RoundChecked{Record: r}, RoundCompleted{Record: r}}
+}
+
+func TestAchievementRules(t *testing.T) {
+	grandStaffC := cleanRound("C5", "C4", "C3")
+	hinted := grandStaffC
+	hinted.Hints = 1
+	wrongFirst := grandStaffC
+	wrongFirst.Marks, wrongFirst.Correct, wrongFirst.Wrong, wrongFirst.Missing, wrongFirst.Solved =
+		[]string{"C5", "D4"}, []string{"C5"}, []string{"D4"}, []string{"C4", "C3"}, false
+	var ninetyNine []gameEvent
+	for i := 0; i < 33; i++ {
+		ninetyNine = append(ninetyNine, checkedThenCompleted(grandStaffC)...)
+	}
+	concat := func(lists ...[]gameEvent) []gameEvent {
+		var all []gameEvent
+		for _, l := range lists {
+			all = append(all, l...)
+		}
+		return all
+	}
+	marked, removed := NoteMarked{Pitch: mustParsePitch("C4")}, NoteRemoved{Pitch: mustParsePitch("C4")}
+
+	tests := []struct {
+		name   string
+		id     string
+		events []gameEvent
+		want   bool
+	}{
+		{"perfect grand staff", "first-perfect-grand-staff", checkedThenCompleted(grandStaffC), true},
+		{"grand staff after a hint", "first-perfect-grand-staff", checkedThenCompleted(hinted), false},
+		{"one staff only", "first-perfect-grand-staff", checkedThenCompleted(cleanRound("C5", "C4")), false},
+
+		{"no note taken back", "no-eraser", concat([]gameEvent{marked}, checkedThenCompleted(grandStaffC)), true},
+		{"a note taken back", "no-eraser", []gameEvent{RoundStarted{}, marked, removed, marked, RoundChecked{Record: grandStaffC}}, false},
+		{"taken back in a round that was never completed", "no-eraser",
+			[]gameEvent{RoundStarted{}, marked, removed, RoundStarted{}, marked, RoundChecked{Record: grandStaffC}}, true},
+
+		{"99 clean notes", "hundred-clean-notes", ninetyNine, false},
+		{"102 clean notes", "hundred-clean-notes", concat(ninetyNine, checkedThenCompleted(grandStaffC)), true},
+		{"a mistake put right resets the run", "hundred-clean-notes", concat(ninetyNine,
+			[]gameEvent{RoundStarted{}, RoundChecked{Record: wrongFirst}, RoundChecked{Record: hinted}, RoundCompleted{Record: hinted}},
+			checkedThenCompleted(grandStaffC)), false},
+		{"a round solved after a hint doesn't count", "hundred-clean-notes", concat(ninetyNine, checkedThenCompleted(hinted)), false},
+		{"an unanswered round resets the run", "hundred-clean-notes", concat(ninetyNine,
+			[]gameEvent{RoundStarted{}, RoundCompleted{Record: roundRecord{Targets: []string{"A4"}, Missing: []string{"A4"}}}},
+			checkedThenCompleted(grandStaffC)), false},
+	}
+	for _, tt := range tests {
+		var tracker achievementTracker
+		for _, e := range tt.events {
+			tracker.observe(e)
+		}
+		if _, got := tracker.State.Unlocked[tt.id]; got != tt.want {
+			t.Errorf("%s: %s unlocked = %v, want %v", tt.name, tt.id, got, tt.want)
+		}
+	}
+}
+
+// The state is written when a round ends or something is unlocked, and not for every note in between.
+func TestAchievementTrackerSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), achievementsFileName)
+	var tracker achievementTracker
+	if err := tracker.load(path); err != nil {
+		t.Fatal(err)
+	}
+	saved := func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	tracker.observe(RoundStarted{})
+	tracker.observe(NoteMarked{Pitch: mustParsePitch("C4")}) // unlocks First Note
+	if !saved() {
+		t.Fatal("an unlock wasn't saved")
+	}
+	os.Remove(path)
+	tracker.observe(NoteMarked{Pitch: mustParsePitch("D4")})
+	tracker.observe(NoteRemoved{Pitch: mustParsePitch("D4")})
+	tracker.observe(RoundChecked{Record: cleanRound("C4")})
+	if saved() {
+		t.Fatal("saved before the round was over")
+	}
+	tracker.observe(RoundCompleted{Record: cleanRound("C4")})
+	if !saved() {
+		t.Fatal("the end of the round wasn't saved")
+	}
+
+	var reloaded achievementTracker
+	if err := reloaded.load(path); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.State.Marked != 2 || !reflect.DeepEqual(unlockedIDs(reloaded.State.Unlocked), unlockedIDs(tracker.State.Unlocked)) {
+		t.Errorf("reloaded %+v, want %+v", reloaded.State, tracker.State)
+	}
+	if leftovers, _ := filepath.Glob(path + ".tmp"); len(leftovers) > 0 {
+		t.Errorf("left behind %q", leftovers)
+	}
+}
+
+func unlockedIDs(m map[string]time.Time) map[string]bool {
+	set := map[string]bool{}
+	for k := range m {
+		set[k] = true
+	}
+	return set
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Blitz mode. ::: Find-the-note rounds against a countdown: a round advances by itself the moment its marks are exactly
+// the targets, and points reward speed and accuracy while every wrong mark costs some back. The countdown runs off a
+// clock (see clock.go), so a fake clock can run a whole blitz in an instant.
+
+const (
+	blitzLength       = 60 * time.Second
+	blitzSolvePoints  = 100                    // for every round solved
+	blitzSpeedBonus   = 100                    // on top, for a round solved at once; it shrinks to nothing at blitzSlowRound
+	blitzSlowRound    = 10 * time.Second       // a round this slow earns no speed bonus
+	blitzWrongPenalty = 30                     // taken off for every mark that isn't a target
+	blitzTickEvery    = 250 * time.Millisecond // how often the countdown reports the time left
+)
+
+// blitzScore keeps the score of one blitz.
+type blitzScore struct {
+	Points     int
+	Solved     int
+	WrongMarks int
+	Fastest    time.Duration // the quickest round solved
+	Total      time.Duration // over all the rounds solved
+}
+
+// solve scores a round solved after took, returning the points it earned.
+func (s *blitzScore) solve(took time.Duration) int {
+	points := blitzSolvePoints
+	if took < blitzSlowRound {
+		points += int(float64(blitzSpeedBonus) * float64(blitzSlowRound-took) / float64(blitzSlowRound))
+	}
+	s.Points += points
+	s.Solved++
+	s.Total += took
+	if s.Solved == 1 || took < s.Fastest { // the first round solved sets it, however quick
+		s.Fastest = took
+	}
+	return points
+}
+
+// wrongMark takes the penalty for a mark that isn't a target; the score doesn't go below zero.
+func (s *blitzScore) wrongMark() {
+	s.WrongMarks++
+	s.Points -= blitzWrongPenalty
+	if s.Points < 0 {
+		s.Points = 0
+	}
+}
+
+// summary is the end-of-blitz report.
+func (s blitzScore) summary() string {
+	if s.Solved == 0 {
+		return fmt.Sprintf("Time's up! No rounds solved, %d wrong marks. Score: %d", s.WrongMarks, s.Points)
+	}
+	return fmt.Sprintf("Time's up! %d rounds solved (%.1fs on average, fastest %.1fs), %d wrong marks. Score: %d",
+		s.Solved, (s.Total / time.Duration(s.Solved)).Seconds(), s.Fastest.Seconds(), s.WrongMarks, s.Points)
+}
+
+// countdown counts a length of time down on its own goroutine, reporting the time left every tick and calling OnDone
+// once it has run out. Both callbacks run on the countdown's goroutine, so they take gameMu before touching the game
+// (see withGame); Stop never waits for them, so it may be called holding gameMu.
+type countdown struct {
+	clock  clock
+	Length time.Duration
+	OnTick func(left time.Duration)
+	OnDone func()
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+func newCountdown(c clock, length time.Duration, onTick func(time.Duration), onDone func()) *countdown {
+	return &countdown{clock: c, Length: length, OnTick: onTick, OnDone: onDone}
+}
+
+// Start (or restart) the countdown from its full length; it returns the start time.
+func (c *countdown) Start() time.Time {
+	c.Stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	start := c.clock.Now()
+	c.stop = make(chan struct{})
+	go c.run(start, c.stop)
+	return start
+}
+
+// Stop abandons the countdown without calling OnDone; it is safe to call when it isn't running.
+func (c *countdown) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
+
+func (c *countdown) run(start time.Time, stop chan struct{}) {
+	end := start.Add(c.Length)
+	for {
+		select {
+		case <-stop:
+			return // stopped while waiting: no more callbacks
+		default:
+		}
+		left := end.Sub(c.clock.Now())
+		if left < 0 {
+			left = 0
+		}
+		if c.OnTick != nil {
+			c.OnTick(left)
+		}
+		if left == 0 {
+			if c.OnDone != nil {
+				c.OnDone()
+			}
+			return
+		}
+		wait := blitzTickEvery
+		if left < wait {
+			wait = left
+		}
+		select {
+		case <-stop:
+			return
+		case <-c.clock.After(wait):
+		}
+	}
+}
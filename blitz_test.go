@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// The countdown runs out at its length, however late its wake-ups are, reporting less time left every tick and calling
+// OnDone exactly once.
+func TestCountdownExpires(t *testing.T) {
+	tests := []struct {
+		length time.Duration
+		late   time.Duration // added to every wake-up
+	}{
+		{blitzLength, 0},
+		{time.Second, 0},
+		{1100 * time.Millisecond, 0}, // the last wait is shorter than a tick
+		{time.Second, 40 * time.Millisecond},
+		{3 * time.Second, 249 * time.Millisecond},
+		{100 * time.Millisecond, 0},
+		{0, 0},
+	}
+	for _, tt := range tests {
+		clk := newFakeClock()
+		ticks := make(chan time.Duration, 1000)
+		done := make(chan time.Time, 2)
+		c := newCountdown(clk, tt.length, func(left time.Duration) { ticks <- left }, func() { done <- clk.Now() })
+		start := c.Start()
+		var ended time.Time
+		for ended.IsZero() {
+			select {
+			case wait := <-clk.Sleeps:
+				if wait > blitzTickEvery {
+					t.Fatalf("%v countdown: slept %v between ticks", tt.length, wait)
+				}
+				clk.Advance(wait + tt.late)
+			case ended = <-done:
+			}
+		}
+		close(ticks)
+
+		if took := ended.Sub(start); took < tt.length || took > tt.length+tt.late {
+			t.Errorf("%v countdown, %v late: ran out after %v", tt.length, tt.late, took)
+		}
+		prev, count := tt.length+1, 0
+		for left := range ticks {
+			if left >= prev {
+				t.Errorf("%v countdown, %v late: %v left after %v", tt.length, tt.late, left, prev)
+			}
+			prev = left
+			count++
+		}
+		if prev != 0 {
+			t.Errorf("%v countdown, %v late: the last tick had %v left, want 0", tt.length, tt.late, prev)
+		}
+		if limit := int(tt.length/blitzTickEvery) + 2; count > limit {
+			t.Errorf("%v countdown: %d ticks, want at most %d", tt.length, count, limit)
+		}
+		select {
+		case <-done:
+			t.Errorf("%v countdown: OnDone called twice", tt.length)
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+}
+
+func TestCountdownStop(t *testing.T) {
+	clk := newFakeClock()
+	done := make(chan struct{}, 1)
+	c := newCountdown(clk, time.Second, nil, func() { done <- struct{}{} })
+	c.Start()
+	<-clk.Sleeps
+	c.Stop()
+	clk.Advance(time.Minute)
+	select {
+	case <-done:
+		t.Error("a stopped countdown called OnDone")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBlitzScore(t *testing.T) {
+	tests := []struct {
+		name          string
+		solves        []time.Duration
+		wrong         int // wrong marks, taken after the solves
+		points        int
+		fastest, mean time.Duration
+	}{
+		{"nothing", nil, 0, 0, 0, 0},
+		{"at once", []time.Duration{0}, 0, blitzSolvePoints + blitzSpeedBonus, 0, 0},
+		{"half the slow time", []time.Duration{blitzSlowRound / 2}, 0, blitzSolvePoints + blitzSpeedBonus/2, blitzSlowRound / 2, blitzSlowRound / 2},
+		{"too slow for a bonus", []time.Duration{blitzSlowRound, 2 * blitzSlowRound}, 0, 2 * blitzSolvePoints, blitzSlowRound, 3 * blitzSlowRound / 2},
+		{"a wrong mark", []time.Duration{blitzSlowRound}, 1, blitzSolvePoints - blitzWrongPenalty, blitzSlowRound, blitzSlowRound},
+		{"never below zero", nil, 3, 0, 0, 0},
+		{"at once, then slower", []time.Duration{0, blitzSlowRound}, 0, 2*blitzSolvePoints + blitzSpeedBonus, 0, blitzSlowRound / 2},
+		{"fastest of three", []time.Duration{4 * time.Second, 2 * time.Second, 6 * time.Second}, 0,
+			3*blitzSolvePoints + 6*blitzSpeedBonus/10 + 8*blitzSpeedBonus/10 + 4*blitzSpeedBonus/10, 2 * time.Second, 4 * time.Second},
+	}
+	for _, tt := range tests {
+		var s blitzScore
+		for _, took := range tt.solves {
+			s.solve(took)
+		}
+		for i := 0; i < tt.wrong; i++ {
+			s.wrongMark()
+		}
+		if s.Points != tt.points || s.Solved != len(tt.solves) || s.WrongMarks != tt.wrong || s.Fastest != tt.fastest {
+			t.Errorf("%s: got %+v, want %d points and fastest %v", tt.name, s, tt.points, tt.fastest)
+		}
+		if s.Solved > 0 && s.Total/time.Duration(s.Solved) != tt.mean {
+			t.Errorf("%s: mean %v, want %v", tt.name, s.Total/time.Duration(s.Solved), tt.mean)
+		}
+	}
+}
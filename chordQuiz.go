@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The chord identification quiz, the reverse of chord building. ::: A chord is drawn in one column of the Grand Staff
+// (on one staff in close position, or, at the harder level, with the bass alone on the bass staff and the upper voices
+// on the treble) and the student names its root, quality and inversion.
+
+// chordRootNames are the roots the quiz uses, and the choices in its root selector.
+var chordRootNames = []string{"C", "C#", "Db", "D", "Eb", "E", "F", "F#", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+// Staff steps (see Pitch.step) bounding the Grand Staff's notes, ledger notes included.
+var (
+	trebleLowStep = mustParsePitch("C4").step()
+	trebleTopStep = mustParsePitch("A5").step()
+	bassLowStep   = mustParsePitch("F2").step()
+)
+
+// stackUp places pitches from the bottom up: the first at the lowest octave on or above step from, and each of the
+// rest at the lowest octave above the one before. The octaves of the given pitches are ignored.
+func stackUp(tones []Pitch, from int) []Pitch {
+	var stacked []Pitch
+	for _, t := range tones {
+		p := t
+		p.Octave = 0
+		for p.step() < from {
+			p.Octave++
+		}
+		stacked = append(stacked, p)
+		from = p.step() + 1
+	}
+	return stacked
+}
+
+// shiftOctaves moves every pitch by n octaves.
+func shiftOctaves(pitches []Pitch, n int) []Pitch {
+	for i := range pitches {
+		pitches[i].Octave += n
+	}
+	return pitches
+}
+
+// voiceChord spells a chord out as notes on the Grand Staff, lowest first. A close voicing stacks the tones, from the
+// bass up, on one staff; an open one puts the bass on the bass staff and four-part upper voices on the treble, doubling
+// the root of a triad.
+func voiceChord(c chord, open, treble bool) []Pitch {
+	tones := c.tones()
+	order := append(append([]Pitch{}, tones[c.Inversion:]...), tones[:c.Inversion]...) // the bass, then upwards
+	if !open {
+		if !treble {
+			return stackUp(order, bassLowStep)
+		}
+		voiced := stackUp(order, trebleLowStep)
+		if voiced[len(voiced)-1].step() > trebleTopStep {
+			voiced = shiftOctaves(voiced, -1)
+		}
+		return voiced
+	}
+	bass := stackUp(order[:1], bassLowStep+1) // on the staff, not below it
+	upper := order[1:]
+	if len(tones) == 3 {
+		upper = []Pitch{tones[1], tones[2], tones[0]} // root position: third, fifth, root
+		switch c.Inversion {
+		case 1:
+			upper = []Pitch{tones[0], tones[2], tones[0]}
+		case 2:
+			upper = []Pitch{tones[0], tones[1], tones[0]}
+		}
+	}
+	voiced := stackUp(upper, mustParsePitch("E4").step())
+	if voiced[len(voiced)-1].step() > trebleTopStep {
+		voiced = shiftOctaves(voiced, -1)
+	}
+	return append(bass, voiced...)
+}
+
+// randomQuizChord picks a root and quality whose tones need no double sharps or flats, and an inversion.
+func randomQuizChord(rng *rand.Rand, sevenths bool) chord {
+	for {
+		c := chord{Root: mustParsePitch(chordRootNames[rng.Intn(len(chordRootNames))] + "4"), Quality: chordQuality(rng.Intn(len(chordQualities)))}
+		if !sevenths && c.Quality > augmentedTriad {
+			continue
+		}
+		simple := true
+		for _, t := range c.tones() {
+			simple = simple && t.Accidental >= -1 && t.Accidental <= 1
+		}
+		if simple {
+			c.Inversion = rng.Intn(len(c.tones()))
+			return c
+		}
+	}
+}
+
+// drawChord draws voiced notes as whole notes in one column at x, with their accidentals. The upper note of a second
+// is set off to the right, as engravers do.
+func drawChord(notePositions []NotePosition, voiced []Pitch, x float32, c color.Color) []fyne.CanvasObject {
+	voiced = append([]Pitch{}, voiced...)
+	sort.Slice(voiced, func(i, j int) bool { return voiced[i].step() < voiced[j].step() })
+	var objects []fyne.CanvasObject
+	shifted := false
+	for i, p := range voiced {
+		pos, ok := findNotePosition(notePositions, p.natural().String())
+		if !ok {
+			continue
+		}
+		noteX := x
+		shifted = i > 0 && p.step() == voiced[i-1].step()+1 && !shifted
+		if shifted {
+			noteX += grandStaffMetrics.HeadW
+		}
+		objects = append(objects, drawNote(noteGlyph{Value: wholeNote, X: noteX, Y: pos.Y}, grandStaffMetrics, c)...)
+		objects = append(objects, drawLedger(pos, noteX)...)
+		if p.Accidental != 0 {
+			objects = append(objects, drawAccidental(p.Accidental, x, pos.Y, grandStaffMetrics, c))
+		}
+	}
+	return objects
+}
+
+// chordSelectors are the quiz's answer widgets: root, quality and inversion.
+type chordSelectors struct {
+	Root, Quality, Inversion *widget.Select
+	Box                      *fyne.Container
+}
+
+func newChordSelectors() *chordSelectors {
+	var qualities []string
+	for _, q := range chordQualities {
+		qualities = append(qualities, q.Name)
+	}
+	s := &chordSelectors{
+		Root:      widget.NewSelect(chordRootNames, nil),
+		Quality:   widget.NewSelect(qualities, nil),
+		Inversion: widget.NewSelect(inversionNames, nil),
+	}
+	s.Root.PlaceHolder, s.Quality.PlaceHolder, s.Inversion.PlaceHolder = "(root)", "(quality)", "(inversion)"
+	s.Box = container.NewHBox(widget.NewLabel("Root"), s.Root, widget.NewLabel("Quality"), s.Quality, widget.NewLabel("Inversion"), s.Inversion)
+	return s
+}
+
+// reset clears the selections for a new chord.
+func (s *chordSelectors) reset() {
+	s.Root.ClearSelected()
+	s.Quality.ClearSelected()
+	s.Inversion.ClearSelected()
+}
+
+// judge compares the selections with c, naming each part that is wrong.
+func (s *chordSelectors) judge(c chord) (string, bool) {
+	if s.Root.Selected == "" || s.Quality.SelectedIndex() < 0 || s.Inversion.SelectedIndex() < 0 {
+		return "Pick a root, a quality and an inversion first", false
+	}
+	var wrong []string
+	if s.Root.Selected != c.Root.name() {
+		wrong = append(wrong, fmt.Sprintf("the root is not %s", s.Root.Selected))
+	}
+	if chordQuality(s.Quality.SelectedIndex()) != c.Quality {
+		wrong = append(wrong, fmt.Sprintf("it is not a %s", s.Quality.Selected))
+	}
+	if s.Inversion.SelectedIndex() != c.Inversion {
+		wrong = append(wrong, fmt.Sprintf("it is not in %s", s.Inversion.Selected))
+	}
+	if len(wrong) > 0 {
+		return "Not quite: " + strings.Join(wrong, ", "), false
+	}
+	return fmt.Sprintf("Correct! That's %s", c), true
+}
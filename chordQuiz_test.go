@@ -0,0 +1,97 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestVoiceChord(t *testing.T) {
+	cMajor := chord{Root: mustParsePitch("C4"), Quality: majorTriad}
+	cOverE := chord{Root: mustParsePitch("C4"), Quality: majorTriad, Inversion: 1}
+	aMinorOverC := chord{Root: mustParsePitch("A4"), Quality: minorTriad, Inversion: 1}
+	g7 := chord{Root: mustParsePitch("G4"), Quality: dominantSeventh}
+	g7OverD := chord{Root: mustParsePitch("G4"), Quality: dominantSeventh, Inversion: 2}
+	tests := []struct {
+		name         string
+		c            chord
+		open, treble bool
+		want         string
+	}{
+		{"close on the treble staff", cMajor, false, true, "C4 E4 G4"},
+		{"close, first inversion", aMinorOverC, false, true, "C4 E4 A4"},
+		{"close seventh, second inversion", g7OverD, false, true, "D4 F4 G4 B4"},
+		{"close on the bass staff", cMajor, false, false, "C3 E3 G3"},
+		{"open, the root doubled", cMajor, true, true, "C3 E4 G4 C5"},
+		{"open, first inversion, brought down an octave", cOverE, true, true, "E3 C4 G4 C5"},
+		{"open seventh, from the lowest bass", g7, true, true, "G2 B4 D5 F5"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range voiceChord(tt.c, tt.open, tt.treble) {
+			got = append(got, p.String())
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: %s voiced as %v, want %s", tt.name, tt.c, got, tt.want)
+		}
+	}
+}
+
+// The quiz's chords keep to single sharps and flats, leave out seventh chords unless asked for them, and name an
+// inversion the chord has.
+func TestRandomQuizChord(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	sevenths := false
+	for i := 0; i < 2000; i++ {
+		withSevenths := i%2 == 1
+		c := randomQuizChord(rng, withSevenths)
+		if !withSevenths && c.Quality > augmentedTriad {
+			t.Fatalf("%s without sevenths", c)
+		}
+		sevenths = sevenths || c.Quality > augmentedTriad
+		if c.Inversion < 0 || c.Inversion >= len(c.tones()) {
+			t.Fatalf("%s: inversion %d", c, c.Inversion)
+		}
+		for _, p := range c.tones() {
+			if p.Accidental < -1 || p.Accidental > 1 {
+				t.Fatalf("%s has %s", c, p)
+			}
+		}
+	}
+	if !sevenths {
+		t.Error("no seventh chords in 1000 rounds that allow them")
+	}
+}
+
+func TestChordSelectorsJudge(t *testing.T) {
+	test.NewTempApp(t)
+	ebOverG := chord{Root: mustParsePitch("Eb4"), Quality: majorTriad, Inversion: 1}
+	tests := []struct {
+		root, quality, inversion string
+		solved                   bool
+		says                     []string
+	}{
+		{"Eb", "major triad", "first inversion", true, []string{"Correct!"}},
+		{"Db", "major triad", "first inversion", false, []string{"the root is not Db"}},
+		{"Eb", "minor triad", "root position", false, []string{"it is not a minor triad", "it is not in root position"}},
+		{"Eb", "", "first inversion", false, []string{"Pick a root"}},
+	}
+	s := newChordSelectors()
+	for _, tt := range tests {
+		s.reset()
+		s.Root.SetSelected(tt.root)
+		s.Quality.SetSelected(tt.quality)
+		s.Inversion.SetSelected(tt.inversion)
+		msg, solved := s.judge(ebOverG)
+		if solved != tt.solved {
+			t.Errorf("%s %s %s: solved %v: %s", tt.root, tt.quality, tt.inversion, solved, msg)
+		}
+		for _, part := range tt.says {
+			if !strings.Contains(msg, part) {
+				t.Errorf("%s %s %s: %q doesn't say %q", tt.root, tt.quality, tt.inversion, msg, part)
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Intervals and chords, built on the pitch model. ::: An interval counts both letter steps and semitones, so stacking
+// a minor third on D gives F (not E#), and the spelling of every chord tone comes out right.
+
+// interval is the distance between two pitches: Steps letters up, Semitones keys up.
+type interval struct {
+	Steps     int
+	Semitones int
+}
+
+var (
+	minorThirdInterval        = interval{2, 3}
+	majorThirdInterval        = interval{2, 4}
+	diminishedFifthInterval   = interval{4, 6}
+	perfectFifthInterval      = interval{4, 7}
+	augmentedFifthInterval    = interval{4, 8}
+	diminishedSeventhInterval = interval{6, 9}
+	minorSeventhInterval      = interval{6, 10}
+	majorSeventhInterval      = interval{6, 11}
+)
+
+// transpose moves p up by iv, spelling the result on the letter iv.Steps above.
+func (p Pitch) transpose(iv interval) Pitch {
+	q := naturalAt(p.step() + iv.Steps)
+	q.Accidental = p.MIDI() + iv.Semitones - q.MIDI()
+	return q
+}
+
+// chordQuality is what kind of triad or seventh chord is stacked on the root.
+type chordQuality int
+
+const (
+	majorTriad chordQuality = iota
+	minorTriad
+	diminishedTriad
+	augmentedTriad
+	dominantSeventh
+	majorSeventh
+	minorSeventh
+	halfDiminishedSeventh
+	diminishedSeventh
+)
+
+// chordQualities describes each quality: its name, its chord-symbol suffix, and its tones above the root.
+var chordQualities = []struct {
+	Name      string
+	Symbol    string
+	Intervals []interval
+}{
+	majorTriad:            {"major triad", "", []interval{majorThirdInterval, perfectFifthInterval}},
+	minorTriad:            {"minor triad", "m", []interval{minorThirdInterval, perfectFifthInterval}},
+	diminishedTriad:       {"diminished triad", "dim", []interval{minorThirdInterval, diminishedFifthInterval}},
+	augmentedTriad:        {"augmented triad", "aug", []interval{majorThirdInterval, augmentedFifthInterval}},
+	dominantSeventh:       {"dominant seventh", "7", []interval{majorThirdInterval, perfectFifthInterval, minorSeventhInterval}},
+	majorSeventh:          {"major seventh", "maj7", []interval{majorThirdInterval, perfectFifthInterval, majorSeventhInterval}},
+	minorSeventh:          {"minor seventh", "m7", []interval{minorThirdInterval, perfectFifthInterval, minorSeventhInterval}},
+	halfDiminishedSeventh: {"half-diminished seventh", "m7b5", []interval{minorThirdInterval, diminishedFifthInterval, minorSeventhInterval}},
+	diminishedSeventh:     {"diminished seventh", "dim7", []interval{minorThirdInterval, diminishedFifthInterval, diminishedSeventhInterval}},
+}
+
+var inversionNames = []string{"root position", "first inversion", "second inversion", "third inversion"}
+
+// chord is a quality stacked on a root, with one of its tones in the bass.
+type chord struct {
+	Root      Pitch
+	Quality   chordQuality
+	Inversion int // 0 for root position; the index of the bass note among the tones
+}
+
+// tones returns the chord tones in root position, root first.
+func (c chord) tones() []Pitch {
+	tones := []Pitch{c.Root}
+	for _, iv := range chordQualities[c.Quality].Intervals {
+		tones = append(tones, c.Root.transpose(iv))
+	}
+	return tones
+}
+
+// bass is the tone that has to be lowest.
+func (c chord) bass() Pitch { return c.tones()[c.Inversion] }
+
+// symbol is the chord symbol without the octave, e.g. "Dm" or "G7".
+func (c chord) symbol() string {
+	return c.Root.name() + chordQualities[c.Quality].Symbol
+}
+
+// String names the chord the way a round asks for it, e.g. "D minor triad (Dm) in root position".
+func (c chord) String() string {
+	return fmt.Sprintf("%s %s (%s) in %s", c.Root.name(), chordQualities[c.Quality].Name, c.symbol(), inversionNames[c.Inversion])
+}
+
+// chordRound asks for a chord to be built on one staff of the Grand Staff.
+type chordRound struct {
+	Chord  chord
+	Treble bool // the treble staff; otherwise the bass staff
+}
+
+func (r chordRound) staffName() string {
+	if r.Treble {
+		return "treble"
+	}
+	return "bass"
+}
+
+// randomChordRound picks a chord on a natural root, a random inversion, and a staff. Its tones may need sharps and
+// flats (the augmented fifth and diminished seventh usually do), but never a double one: there is none to place.
+func randomChordRound(rng *rand.Rand, sevenths bool) chordRound {
+	for {
+		c := chord{Root: naturalAt(rng.Intn(7)), Quality: chordQuality(rng.Intn(int(augmentedTriad) + 1))}
+		if sevenths {
+			c.Quality = dominantSeventh + chordQuality(rng.Intn(int(diminishedSeventh-dominantSeventh)+1))
+		}
+		placeable := true
+		for _, t := range c.tones() {
+			placeable = placeable && t.Accidental >= -1 && t.Accidental <= 1
+		}
+		if !placeable {
+			continue
+		}
+		c.Inversion = rng.Intn(len(c.tones()))
+		return chordRound{Chord: c, Treble: rng.Intn(2) == 0}
+	}
+}
+
+// judgeChord scores the marked notes as a set: every chord tone present and nothing else, the right tone lowest, all
+// of them on the asked-for staff, and no doubling that the usual voice-leading rules forbid.
+func judgeChord(r chordRound, marks []MarkedNote) (string, bool) {
+	if len(marks) == 0 {
+		return fmt.Sprintf("Place the notes of %s on the %s staff", r.Chord, r.staffName()), false
+	}
+	tones := r.Chord.tones()
+	role := map[string]int{} // letter -> index among the chord tones
+	for i, t := range tones {
+		role[t.Letter] = i
+	}
+	var problems []string
+	count := map[string]int{}
+	var placed []Pitch
+	for _, m := range marks {
+		p := mustParsePitch(m.Pitch)
+		placed = append(placed, p)
+		count[p.Letter]++
+		if i, ok := role[p.Letter]; !ok {
+			problems = append(problems, fmt.Sprintf("%s is not in %s", p, r.Chord.symbol()))
+		} else if p.Accidental != tones[i].Accidental {
+			problems = append(problems, fmt.Sprintf("%s should be %s", p, tones[i].name()))
+		}
+		if (staffMiddleY(m.Y) == 220) != r.Treble {
+			problems = append(problems, fmt.Sprintf("%s is not on the %s staff", p, r.staffName()))
+		}
+	}
+	for _, t := range tones {
+		if count[t.Letter] == 0 {
+			problems = append(problems, fmt.Sprintf("%s is missing", t.name()))
+		}
+	}
+	sort.Slice(placed, func(i, j int) bool { return placed[i].step() < placed[j].step() })
+	if bass := r.Chord.bass(); placed[0].Letter != bass.Letter {
+		problems = append(problems, fmt.Sprintf("%s needs %s in the bass, not %s", inversionNames[r.Chord.Inversion], bass.Letter, placed[0].Letter))
+	}
+	for letter, n := range count {
+		if n < 2 {
+			continue
+		}
+		i, ok := role[letter]
+		switch {
+		case !ok:
+		case i == 3:
+			problems = append(problems, fmt.Sprintf("don't double the seventh (%s)", letter))
+		case r.Chord.Quality == majorTriad && i == 1:
+			problems = append(problems, fmt.Sprintf("don't double the third of a major triad (%s)", letter))
+		case r.Chord.Quality == diminishedTriad && i != 1:
+			problems = append(problems, fmt.Sprintf("in a diminished triad, double the third rather than %s", letter))
+		}
+	}
+	if len(marks) > 4 {
+		problems = append(problems, "use at most four notes")
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return strings.Join(problems, "; "), false
+	}
+	return fmt.Sprintf("Perfect! That's %s", r.Chord), true
+}
+
+// chordColumnX is where chord tones are stacked on the Grand Staff: the usual column, ledger notes included.
+const chordColumnX = 300
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Every quality comes up, accidentals and all, and every tone can be placed with a single sharp or flat.
+func TestRandomChordRoundQualities(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	seen := map[chordQuality]bool{}
+	for i := 0; i < 2000; i++ {
+		r := randomChordRound(rng, i%2 == 1)
+		seen[r.Chord.Quality] = true
+		for _, tone := range r.Chord.tones() {
+			if tone.Accidental < -1 || tone.Accidental > 1 {
+				t.Fatalf("%s has %s, which can't be placed", r.Chord, tone.name())
+			}
+		}
+	}
+	for q := range chordQualities {
+		if !seen[chordQuality(q)] {
+			t.Errorf("no %s in 2000 rounds", chordQualities[q].Name)
+		}
+	}
+}
+
+func TestJudgeChordAccidentals(t *testing.T) {
+	treble := func(pitches ...string) []MarkedNote {
+		var marks []MarkedNote
+		for _, p := range pitches {
+			marks = append(marks, MarkedNote{Pitch: p, X: chordColumnX, Y: 200})
+		}
+		return marks
+	}
+	cAug := chordRound{Chord: chord{Root: mustParsePitch("C4"), Quality: augmentedTriad}, Treble: true}
+	bDim7 := chordRound{Chord: chord{Root: mustParsePitch("B3"), Quality: diminishedSeventh}, Treble: true}
+	tests := []struct {
+		name  string
+		round chordRound
+		marks []MarkedNote
+		ok    bool
+		want  string // part of the feedback
+	}{
+		{"augmented", cAug, treble("C4", "E4", "G#4"), true, "Perfect"},
+		{"augmented, fifth left natural", cAug, treble("C4", "E4", "G4"), false, "G4 should be G#"},
+		{"augmented, fifth flattened", cAug, treble("C4", "E4", "Gb4"), false, "Gb4 should be G#"},
+		{"diminished seventh", bDim7, treble("B4", "D5", "F5", "Ab5"), true, "Perfect"},
+		{"diminished seventh, spelled G#", bDim7, treble("B4", "D5", "F5", "G#5"), false, "G#5 is not in Bdim7"},
+		{"diminished seventh, seventh missing", bDim7, treble("B4", "D5", "F5"), false, "Ab is missing"},
+	}
+	for _, tt := range tests {
+		msg, ok := judgeChord(tt.round, tt.marks)
+		if ok != tt.ok || !strings.Contains(msg, tt.want) {
+			t.Errorf("%s: got %q, %v; want %q, %v", tt.name, msg, ok, tt.want, tt.ok)
+		}
+	}
+}
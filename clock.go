@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// clock is the app's source of time. ::: Anything that schedules against the wall clock (the metronome, timed modes)
+// takes a clock instead of calling the time package directly, so a fake clock can step time by hand.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the clock backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// gameMu guards the game's state. ::: Fyne 2.5 (which has no fyne.Do) calls the handlers of taps, buttons, menus and
+// dialogs on its event goroutine, while MIDI input, the metronome and the countdowns call back on goroutines of their
+// own. Whatever reads or changes the game's state (the marks, the round, the history, the settings) holds gameMu, on
+// whichever goroutine it runs. Fyne's widgets lock themselves, so either side may update them too. gameMu isn't
+// reentrant: code that already holds it calls the other functions directly, never through withGame or locked.
+var gameMu sync.Mutex
+
+// withGame runs f holding gameMu.
+func withGame(f func()) {
+	gameMu.Lock()
+	defer gameMu.Unlock()
+	f()
+}
+
+// locked wraps a button or menu handler so that it runs holding gameMu.
+func locked(f func()) func() {
+	return func() { withGame(f) }
+}
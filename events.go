@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Game events. ::: The game engine publishes what happens (a round starting, a note marked or taken back, an answer
+// judged, a round over) on an eventBus, and everything that merely reacts to it subscribes: the terminal log, the
+// session history, the piano keyboard's lights, blitz scoring and the achievements. None of them is wired into the
+// click and button handlers, and each can be switched off by cancelling its subscription.
+
+// gameEvent is one of the event types below.
+type gameEvent interface {
+	gameEvent()
+}
+
+// RoundStarted is published once a new round is on screen.
+type RoundStarted struct {
+	Mode    gameMode
+	Round   int   // the round's number in its seed's sequence (see seed.go)
+	Seed    int64 // the seed it was dealt from
+	Target  string
+	Targets []NotePosition // the staff positions to find, in rounds that have them
+}
+
+// NoteMarked is published when a note is put on the staff, by a click or a played key.
+type NoteMarked struct {
+	Pitch Pitch
+	X, Y  float32
+}
+
+// NoteRemoved is published when a marked note is taken back off the staff.
+type NoteRemoved struct {
+	Pitch Pitch
+	X, Y  float32
+}
+
+// RoundChecked is published whenever an answer is judged: a Check, a named letter, a sung or played note.
+type RoundChecked struct {
+	Record roundRecord // the round as judged so far; its Time is not set yet
+}
+
+// RoundCompleted is published when a round is over and belongs in the history.
+type RoundCompleted struct {
+	Record roundRecord
+}
+
+func (RoundStarted) gameEvent()   {}
+func (NoteMarked) gameEvent()     {}
+func (NoteRemoved) gameEvent()    {}
+func (RoundChecked) gameEvent()   {}
+func (RoundCompleted) gameEvent() {}
+
+// subscriber is one subscription to an eventBus.
+type subscriber struct {
+	id int
+	f  func(gameEvent)
+}
+
+// eventBus hands every published event to each subscriber, in the order they subscribed. ::: Delivery is ordered:
+// an event published while another is being delivered (a subscriber that starts a new round, say) waits until every
+// subscriber has had the first, so all of them see the same events in the same order. Events from other goroutines
+// queue up the same way.
+type eventBus struct {
+	mu          sync.Mutex
+	subscribers []subscriber
+	nextID      int
+	queue       []gameEvent
+	delivering  bool
+}
+
+// subscribe adds f to the subscribers; calling the returned cancel removes it again, even in the middle of a delivery,
+// after which f gets no more events.
+func (b *eventBus) subscribe(f func(gameEvent)) (cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subscribers = append(b.subscribers, subscriber{id: id, f: f})
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subscribers {
+			if s.id == id {
+				b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// subscribed reports whether a subscription is still live.
+func (b *eventBus) subscribed(id int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.subscribers {
+		if s.id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// publish delivers e to every subscriber, or queues it behind the event being delivered. If a subscriber panics, the
+// bus is left ready for the next publish, which also delivers whatever was still queued.
+func (b *eventBus) publish(e gameEvent) {
+	b.mu.Lock()
+	b.queue = append(b.queue, e)
+	if b.delivering {
+		b.mu.Unlock()
+		return
+	}
+	b.delivering = true
+	done := false
+	defer func() {
+		if !done { // a subscriber panicked, with b.mu unlocked
+			b.mu.Lock()
+			b.delivering = false
+			b.mu.Unlock()
+		}
+	}()
+	for len(b.queue) > 0 {
+		next := b.queue[0]
+		b.queue = b.queue[1:]
+		subscribers := append([]subscriber(nil), b.subscribers...)
+		b.mu.Unlock()
+		for _, s := range subscribers {
+			if b.subscribed(s.id) {
+				s.f(next)
+			}
+		}
+		b.mu.Lock()
+	}
+	b.delivering, done = false, true
+	b.mu.Unlock()
+}
+
+// logEvent writes the game's events to the terminal, as the game always has.
+func logEvent(e gameEvent) {
+	switch e := e.(type) {
+	case RoundStarted:
+		fmt.Printf("Round %d of seed %d. Target %s notes: %v\n", e.Round, e.Seed, e.Target, e.Targets)
+	case NoteMarked:
+		fmt.Printf("Marked %s at X=%.0f, Y=%.0f\n", e.Pitch, e.X, e.Y)
+	case NoteRemoved:
+		fmt.Printf("Removed note at X=%.0f, Y=%.0f\n", e.X, e.Y)
+	case RoundCompleted:
+		fmt.Printf("Round %d over: %d right, %d wrong, %d missing in %.1fs\n",
+			e.Record.Round, len(e.Record.Correct), len(e.Record.Wrong), len(e.Record.Missing), e.Record.Seconds)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// round is a RoundStarted event told apart by its number.
+func round(n int) gameEvent { return RoundStarted{Round: n} }
+
+// An event published from inside a subscriber waits until every subscriber has had the one being delivered.
+func TestEventBusPublishFromASubscriber(t *testing.T) {
+	var bus eventBus
+	var got []string
+	bus.subscribe(func(e gameEvent) {
+		n := e.(RoundStarted).Round
+		got = append(got, fmt.Sprint("first ", n))
+		if n == 1 {
+			bus.publish(round(2)) // a subscriber that starts the next round
+		}
+	})
+	bus.subscribe(func(e gameEvent) { got = append(got, fmt.Sprint("second ", e.(RoundStarted).Round)) })
+	bus.publish(round(1))
+	if want := []string{"first 1", "second 1", "first 2", "second 2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered %q, want %q", got, want)
+	}
+}
+
+// A subscription cancelled in the middle of a delivery gets nothing more, not even the rest of that event.
+func TestEventBusCancelDuringADelivery(t *testing.T) {
+	var bus eventBus
+	var got []int
+	var cancel func()
+	bus.subscribe(func(e gameEvent) {
+		if e.(RoundStarted).Round == 2 {
+			cancel()
+		}
+	})
+	cancel = bus.subscribe(func(e gameEvent) { got = append(got, e.(RoundStarted).Round) })
+	for n := 1; n <= 3; n++ {
+		bus.publish(round(n))
+	}
+	if want := []int{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered rounds %v after the cancel, want only %v", got, want)
+	}
+}
+
+// A subscriber that panics doesn't leave the bus stuck: the next publish is delivered, and so is what was queued.
+func TestEventBusRecoversFromAPanic(t *testing.T) {
+	var bus eventBus
+	var got []int
+	bus.subscribe(func(e gameEvent) {
+		n := e.(RoundStarted).Round
+		got = append(got, n)
+		if n == 1 {
+			bus.publish(round(2)) // queued behind round 1, when the panic comes
+			panic("subscriber failed")
+		}
+	})
+	func() {
+		defer func() { recover() }()
+		bus.publish(round(1))
+	}()
+	bus.publish(round(3))
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("delivered rounds %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// The Grand Staff itself, as drawn by the game window and the statistics window alike, so that a note's position is
+// the same in both.
+
+// grandStaffNotes are the Grand Staff notes (A5 to F2), top to bottom.
+var grandStaffNotes = []string{
+	"A5", "G5", "F5", "E5", "D5", "C5", "B4", "A4", "G4", "F4", "E4", // Treble
+	"D4", "C4", "B3", // Middle
+	"A3", "G3", "F3", "E3", "D3", "C3", "B2", "A2", "G2", "F2", // Bass
+}
+
+// newNotePositions works out where each of grandStaffNotes sits on the Y-axis.
+func newNotePositions() []NotePosition {
+	notePositions := make([]NotePosition, len(grandStaffNotes))
+	/*
+		make is a built-in function used to create and initialize certain built-in types: slices, maps, and channels. When
+			you see make([]Type, length), it creates a slice of type []Type with a specified length (and optionally a capacity, if provided
+			as a third argument).
+			[]NotePosition: Specifies the slice type—elements are NotePosition structs.
+			len(grandStaffNotes): Sets the length of the slice to 24 (since len(grandStaffNotes) is 24).
+			Result::: notePositions is a slice of 24 NotePosition elements (structs), pre-allocated and initialized with zero values for the
+			::: type (Pitch: "", Y: 0.0 for each element).
+	*/
+
+	// Load the empty notePositions slice:
+	// Calculate and set the Y-axis coordinates for each note to match the staff layout. We could have hardcoded each, but calculating ...
+	// ... them is both fun and less error-prone!
+	for i, note := range grandStaffNotes { // "i" will become 0 through 23
+		if i < 11 { // for the first 11 lines/notes, Treble (A5 to E4), calculate and assign each note its position on the Y-axis.
+			notePositions[i] = NotePosition{Pitch: note, Y: float32(40 + i*30)} // here "i" is 0 for the first iteration...
+			// ... e.g., when i=0, Y=40 A5; i=1, Y=70 G5, i=2, Y=100 F5
+			// when i=3, 40+(3*30)=130 E5 -- i=4 160 D5 -- i=5 190 C5 -- i=6 220 B4 -- i=7 250 A4 -- i=8 280 G4 -- i=9 310 F4 -- i=10 Y= 340 E4
+		} else if i < 13 { // for the next two lines/notes, (D4 and C4), calculate and assign their positions on the Y-axis.
+			notePositions[i] = NotePosition{Pitch: note, Y: float32(370 + (i-11)*30)}
+		} else if i == 13 { // the Y-axis of the B3 note/line is unique...
+			notePositions[i] = NotePosition{Pitch: note, Y: 490} // ... so this one gets hardcoded as Y = 490
+		} else { // the remaining notes on the Bass clef, (A3 to F2), are calculated with respect to the iterated value of "i", thusly:
+			notePositions[i] = NotePosition{Pitch: note, Y: float32(520 + (i-14)*30)}
+		}
+		/*
+					Results:
+					i=0:  {Pitch: "A5", Y: 40}
+			... see above for i=1 to i=9
+					i=10: {Pitch: "E4", Y: 340}
+					i=11: {Pitch: "D4", Y: 370}
+					i=12: {Pitch: "C4", Y: 400}
+
+					i=13: {Pitch: "B3", Y: 490}
+					i=14: {Pitch: "A3", Y: 520}
+					i=23: {Pitch: "F2", Y: 790}
+
+				or:
+
+					notePositions[0] = {Pitch: "A5", Y: 40}
+					notePositions[1] = {Pitch: "G5", Y: 70}
+					...
+					notePositions[23] = {Pitch: "F2", Y: 790}
+		*/
+	}
+	return notePositions
+}
+
+// drawGrandStaff draws the staff lines and the ledger lines for C4 and A5 over a background of the given color, the
+// background first.
+func drawGrandStaff(background color.Color) []fyne.CanvasObject {
+	// Create canvas for the staff: canvas.___ is a fyne object. Compare Fyne calls near top of main.
+	staffCanvas := canvas.NewRectangle(background)
+	staffCanvas.Resize(fyne.NewSize(1000, 1000)) // Needed to apply the colors specified on the previous line; default is very dark grey.
+
+	// Draw the Grand Staff
+	lines := []fyne.CanvasObject{staffCanvas}
+	// Treble staff (E4 bottom, F5 top)
+	for i := 0; i < 5; i++ {
+		y := float32(340 - i*60) // E4 (340), G4 (280), B4 (220), D5 (160), F5 (100) // F5 at 100 ?? i=9 Y=310  -- i=9 310 F5 --
+		// when i=4, 370 - i*60 = 130
+		line := canvas.NewLine(&color.Black)
+		line.Position1 = fyne.NewPos(100, y)
+		line.Position2 = fyne.NewPos(900, y)
+		line.StrokeWidth = 2
+		lines = append(lines, line)
+	}
+
+	// Bass staff (G2 bottom, A3 top)
+	for i := 0; i < 5; i++ {
+		y := float32(760 - i*60) // G2 (760), B2 (700), D3 (640), F3 (580), A3 (520)
+		line := canvas.NewLine(&color.Black)
+		line.Position1 = fyne.NewPos(100, y)
+		line.Position2 = fyne.NewPos(900, y)
+		line.StrokeWidth = 2
+		lines = append(lines, line)
+	}
+	// Middle C ledger line (C4)
+	for x := 400; x < 600; x += 20 {
+		ledger := canvas.NewLine(&color.Black)
+		ledger.Position1 = fyne.NewPos(float32(x), 400)
+		ledger.Position2 = fyne.NewPos(float32(x+10), 400)
+		ledger.StrokeWidth = 2
+		lines = append(lines, ledger)
+	}
+	// A5 ledger line (above G5)
+	for x := 400; x < 600; x += 20 {
+		ledger := canvas.NewLine(&color.Black)
+		ledger.Position1 = fyne.NewPos(float32(x), 40)
+		ledger.Position2 = fyne.NewPos(float32(x+10), 40)
+		ledger.StrokeWidth = 2
+		lines = append(lines, ledger)
+	}
+	return lines
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The guitar fretboard mode. ::: Guitar music is written on a treble clef with a little 8 under it: every note sounds
+// an octave lower than written, so the open low E string (E2) is written as E3, below the staff. One drill shows a
+// written note and asks for every fret that plays it; the other lights a fret and asks for the written note.
+
+// guitarTuning names the open strings, lowest first, at sounding pitch.
+type guitarTuning struct {
+	Name    string
+	Strings []Pitch
+}
+
+var guitarTunings = []guitarTuning{
+	{"Standard (EADGBE)", guitarStrings("E2 A2 D3 G3 B3 E4")},
+	{"Drop D (DADGBE)", guitarStrings("D2 A2 D3 G3 B3 E4")},
+	{"Half step down (Eb)", guitarStrings("D#2 G#2 C#3 F#3 A#3 D#4")},
+	{"DADGAD", guitarStrings("D2 A2 D3 G3 A3 D4")},
+	{"Open G (DGDGBD)", guitarStrings("D2 G2 D3 G3 B3 D4")},
+}
+
+func guitarStrings(names string) []Pitch {
+	var open []Pitch
+	for _, name := range strings.Fields(names) {
+		open = append(open, mustParsePitch(name))
+	}
+	return open
+}
+
+const guitarFrets = 12 // frets shown, counted from the capo
+
+// fretPosition is a place to press: String counts from the lowest string, 0 up; Fret counts from the capo (0 is open).
+type fretPosition struct {
+	String, Fret int
+}
+
+// label names the position the way guitarists do, with the high E as string 1.
+func (f fretPosition) label() string {
+	if f.Fret == 0 {
+		return fmt.Sprintf("string %d open", 6-f.String)
+	}
+	return fmt.Sprintf("string %d, fret %d", 6-f.String, f.Fret)
+}
+
+// fretboard is a tuning with a capo on some fret.
+type fretboard struct {
+	Tuning guitarTuning
+	Capo   int
+}
+
+// sounding is the pitch a position plays.
+func (b fretboard) sounding(f fretPosition) Pitch {
+	return pitchFromMIDI(b.Tuning.Strings[f.String].MIDI() + b.Capo + f.Fret)
+}
+
+// positionsOf finds every position on the board that sounds p (any spelling).
+func (b fretboard) positionsOf(p Pitch) []fretPosition {
+	var found []fretPosition
+	for s := range b.Tuning.Strings {
+		for f := 0; f <= guitarFrets; f++ {
+			if b.sounding(fretPosition{s, f}).MIDI() == p.MIDI() {
+				found = append(found, fretPosition{s, f})
+			}
+		}
+	}
+	return found
+}
+
+// guitarWritten is where a sounding pitch is written for guitar: an octave higher.
+func guitarWritten(sounding Pitch) Pitch {
+	sounding.Octave++
+	return sounding
+}
+
+// Fretboard drawing: the strings run across, high E on top as in tablature, and column 0 (left of the nut) is open.
+const (
+	fretLeft    = 50
+	fretWidth   = 55
+	fretTop     = 25
+	fretSpacing = 30
+)
+
+func fretX(fret int) float32 { // the middle of the space where a finger goes
+	if fret == 0 {
+		return fretLeft - 25
+	}
+	return fretLeft + (float32(fret)-0.5)*fretWidth
+}
+
+func stringY(s, count int) float32 { return fretTop + float32(count-1-s)*fretSpacing }
+
+// fretAt finds the position nearest a click on the fretboard.
+func fretAt(at fyne.Position, count int) fretPosition {
+	s := count - 1 - int((at.Y-fretTop)/fretSpacing+0.5)
+	if s < 0 {
+		s = 0
+	} else if s >= count {
+		s = count - 1
+	}
+	f := 0
+	if at.X > fretLeft {
+		f = int((at.X-fretLeft)/fretWidth) + 1
+	}
+	if f > guitarFrets {
+		f = guitarFrets
+	}
+	return fretPosition{s, f}
+}
+
+// drawFretboard draws the neck, with dots at the usual frets, and a circle on each of the marked positions.
+func drawFretboard(count int, marked map[fretPosition]color.Color) []fyne.CanvasObject {
+	black := color.Black
+	bottom := stringY(0, count)
+	right := float32(fretLeft + guitarFrets*fretWidth)
+	wood := canvas.NewRectangle(color.RGBA{R: 222, G: 184, B: 135, A: 255})
+	wood.Move(fyne.NewPos(fretLeft, fretTop-10))
+	wood.Resize(fyne.NewSize(right-fretLeft, bottom-fretTop+20))
+	objects := []fyne.CanvasObject{wood}
+	for _, f := range []int{3, 5, 7, 9, 12} {
+		dot := canvas.NewCircle(color.RGBA{R: 120, G: 90, B: 60, A: 255})
+		dot.Resize(fyne.NewSize(12, 12))
+		dot.Move(fyne.NewPos(fretX(f)-6, (fretTop+bottom)/2-6))
+		objects = append(objects, dot)
+	}
+	for f := 0; f <= guitarFrets; f++ {
+		width := float32(2)
+		if f == 0 {
+			width = 6 // the nut, or the capo
+		}
+		x := float32(fretLeft + f*fretWidth)
+		objects = append(objects, newStroke(black, x, fretTop-10, x, bottom+10, width))
+	}
+	for s := 0; s < count; s++ { // the low strings are drawn thicker
+		objects = append(objects, newStroke(black, fretLeft-40, stringY(s, count), right, stringY(s, count), 1+float32(s)*0.3))
+	}
+	for f, c := range marked {
+		circle := canvas.NewCircle(c)
+		circle.Resize(fyne.NewSize(20, 20))
+		circle.Move(fyne.NewPos(fretX(f.Fret)-10, stringY(f.String, count)-10))
+		objects = append(objects, circle)
+	}
+	return objects
+}
+
+// The written-pitch staff: one treble staff, with ledger lines down to D3 (an open low string tuned down) and up to B6.
+const (
+	guitarStaffLeft = 30
+	guitarStaffE4Y  = 220 // the bottom line
+	guitarStaffGap  = 20
+)
+
+var (
+	guitarStaffLow  = mustParsePitch("D3").step()
+	guitarStaffHigh = mustParsePitch("B6").step() // fret 12 on the high string, with the capo at 7
+)
+
+func guitarStaffY(step int) float32 {
+	return guitarStaffE4Y - float32(step-mustParsePitch("E4").step())*guitarStaffGap/2
+}
+
+// guitarStaffStep is the step nearest a click.
+func guitarStaffStep(y float32) int {
+	step := mustParsePitch("E4").step() + int(math.Round(float64((guitarStaffE4Y-y)/(guitarStaffGap/2))))
+	if step < guitarStaffLow {
+		return guitarStaffLow
+	}
+	if step > guitarStaffHigh {
+		return guitarStaffHigh
+	}
+	return step
+}
+
+// drawGuitarStaff draws the staff and its "8vb" treble clef, and p (if any) with its ledger lines at x.
+func drawGuitarStaff(width float32, p *Pitch, x float32, c color.Color) []fyne.CanvasObject {
+	black := color.Black
+	e4 := mustParsePitch("E4").step()
+	var objects []fyne.CanvasObject
+	for line := 0; line < 5; line++ {
+		y := guitarStaffY(e4 + 2*line)
+		objects = append(objects, newStroke(black, guitarStaffLeft, y, width-guitarStaffLeft, y, 2))
+	}
+	clef := canvas.NewText("G", black)
+	clef.TextSize = 70
+	clef.Move(fyne.NewPos(guitarStaffLeft+5, guitarStaffY(e4+8)-10))
+	eight := canvas.NewText("8", black)
+	eight.TextSize = 18
+	eight.Move(fyne.NewPos(guitarStaffLeft+22, guitarStaffE4Y+15))
+	objects = append(objects, clef, eight)
+	if p == nil {
+		return objects
+	}
+	m := metricsForGap(guitarStaffGap)
+	y := guitarStaffY(p.step())
+	for step := e4 - 2; step >= p.step(); step -= 2 { // ledger lines below the staff
+		objects = append(objects, newStroke(black, x-m.HeadW, guitarStaffY(step), x+m.HeadW, guitarStaffY(step), 2))
+	}
+	for step := e4 + 10; step <= p.step(); step += 2 { // and above it
+		objects = append(objects, newStroke(black, x-m.HeadW, guitarStaffY(step), x+m.HeadW, guitarStaffY(step), 2))
+	}
+	objects = append(objects, drawNote(noteGlyph{Value: wholeNote, X: x, Y: y}, m, c)...)
+	if p.Accidental != 0 {
+		objects = append(objects, drawAccidental(p.Accidental, x, y, m, c))
+	}
+	return objects
+}
+
+// showGuitarWindow opens the fretboard drills in their own window. The drills draw from rng, holding gameMu.
+func showGuitarWindow(a fyne.App, rng *rand.Rand) {
+	w := a.NewWindow("Guitar Fretboard")
+	const staffWidth, staffHeight = 360, 340
+	boardWidth, boardHeight := float32(fretLeft+guitarFrets*fretWidth+20), float32(fretTop+5*fretSpacing+30)
+
+	var tuningNames []string
+	for _, t := range guitarTunings {
+		tuningNames = append(tuningNames, t.Name)
+	}
+	tuning := widget.NewSelect(tuningNames, nil)
+	tuning.SetSelectedIndex(0)
+	var capoChoices []string
+	for c := 0; c <= 7; c++ {
+		capoChoices = append(capoChoices, strconv.Itoa(c))
+	}
+	capo := widget.NewSelect(capoChoices, nil)
+	capo.SetSelectedIndex(0)
+	drill := widget.NewRadioGroup([]string{"Find every fret for the note", "Write the fret's note on the staff"}, nil)
+	drill.SetSelected("Find every fret for the note")
+	accidental := widget.NewRadioGroup([]string{"Natural", "Sharp (#)", "Flat (b)"}, nil)
+	accidental.Horizontal = true
+	accidental.SetSelected("Natural")
+
+	staff := container.NewWithoutLayout()
+	board := container.NewWithoutLayout()
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+
+	var (
+		redraw    func()
+		b         fretboard
+		target    fretPosition // the position the round is about
+		picked    = map[fretPosition]bool{}
+		written   *Pitch // what the student wrote, in the write-it drill
+		findDrill bool
+	)
+	// The taps, the buttons and the selects read and change the state above holding gameMu (see clock.go), as they do
+	// rng, which every Guitar Fretboard window opened shares.
+	redraw = func() { // rebuilds both drawings, tap areas included, from the round's state
+		marks := map[fretPosition]color.Color{}
+		if findDrill {
+			for f := range picked {
+				marks[f] = color.RGBA{R: 0, G: 90, B: 220, A: 255}
+			}
+		} else {
+			marks[target] = color.RGBA{R: 0, G: 160, B: 0, A: 255}
+		}
+		board.Objects = drawFretboard(len(b.Tuning.Strings), marks)
+		boardArea := canvas.NewRectangle(color.Transparent)
+		boardArea.Resize(fyne.NewSize(boardWidth, boardHeight))
+		board.Add(&TappableCanvas{CanvasObject: boardArea, OnTapped: func(e *fyne.PointEvent) {
+			gameMu.Lock()
+			defer gameMu.Unlock()
+			if !findDrill {
+				return
+			}
+			f := fretAt(e.Position, len(b.Tuning.Strings))
+			if picked[f] {
+				delete(picked, f)
+			} else {
+				picked[f] = true
+			}
+			redraw()
+		}})
+		board.Refresh()
+
+		shown := written
+		c := color.Color(color.RGBA{R: 220, G: 0, B: 0, A: 255})
+		if findDrill {
+			p := guitarWritten(b.sounding(target))
+			shown, c = &p, color.Black
+		}
+		staff.Objects = drawGuitarStaff(staffWidth, shown, staffWidth/2+30, c)
+		staffArea := canvas.NewRectangle(color.Transparent)
+		staffArea.Resize(fyne.NewSize(staffWidth, staffHeight))
+		staff.Add(&TappableCanvas{CanvasObject: staffArea, OnTapped: func(e *fyne.PointEvent) {
+			gameMu.Lock()
+			defer gameMu.Unlock()
+			if findDrill {
+				return
+			}
+			p := naturalAt(guitarStaffStep(e.Position.Y))
+			p.Accidental = map[string]int{"Sharp (#)": 1, "Flat (b)": -1}[accidental.Selected]
+			written = &p
+			redraw()
+		}})
+		staff.Refresh()
+	}
+
+	newDrill := func() {
+		b = fretboard{Tuning: guitarTunings[tuning.SelectedIndex()], Capo: capo.SelectedIndex()}
+		target = fretPosition{String: rng.Intn(len(b.Tuning.Strings)), Fret: rng.Intn(guitarFrets + 1)}
+		picked = map[fretPosition]bool{}
+		written = nil
+		findDrill = drill.Selected != "Write the fret's note on the staff"
+		if findDrill {
+			status.SetText(fmt.Sprintf("Click every fret that plays the written %s (it sounds as %s), then Check",
+				guitarWritten(b.sounding(target)), b.sounding(target)))
+		} else {
+			status.SetText(fmt.Sprintf("Write the green fret (%s) on the staff, then Check", target.label()))
+		}
+		redraw()
+	}
+	check := widget.NewButton("Check", func() {
+		gameMu.Lock()
+		defer gameMu.Unlock()
+		want := b.sounding(target)
+		if findDrill {
+			var missing, wrong []string
+			positions := b.positionsOf(want)
+			for _, f := range positions {
+				if !picked[f] {
+					missing = append(missing, f.label())
+				}
+			}
+			for f := range picked {
+				if b.sounding(f).MIDI() != want.MIDI() {
+					wrong = append(wrong, f.label())
+				}
+			}
+			sort.Strings(missing)
+			sort.Strings(wrong)
+			switch {
+			case len(missing) == 0 && len(wrong) == 0:
+				status.SetText(fmt.Sprintf("Perfect! %s is at %d places", want, len(positions)))
+			default:
+				status.SetText(fmt.Sprintf("Missing: %s. Wrong: %s.", strings.Join(missing, "; "), strings.Join(wrong, "; ")))
+			}
+			return
+		}
+		expected := guitarWritten(want)
+		switch {
+		case written == nil:
+			status.SetText("Click the staff to write the note first")
+		case written.MIDI() == expected.MIDI():
+			status.SetText(fmt.Sprintf("Correct! %s sounds %s and is written %s", target.label(), want, *written))
+		case written.MIDI() == want.MIDI():
+			status.SetText(fmt.Sprintf("That's the sounding pitch; guitar is written an octave higher, as %s", expected))
+		default:
+			status.SetText(fmt.Sprintf("No: %s is written %s, not %s", target.label(), expected, *written))
+		}
+	})
+	next := widget.NewButton("Next", locked(newDrill))
+	tuning.OnChanged = func(string) { withGame(newDrill) }
+	capo.OnChanged = func(string) { withGame(newDrill) }
+	drill.OnChanged = func(string) { withGame(newDrill) }
+	newDrill() // the window is opened holding gameMu
+
+	w.SetContent(container.NewVBox(
+		container.NewHBox(widget.NewLabel("Tuning"), tuning, widget.NewLabel("Capo"), capo),
+		drill,
+		status,
+		container.NewHBox(
+			container.NewGridWrap(fyne.NewSize(staffWidth, staffHeight), staff),
+			container.NewGridWrap(fyne.NewSize(boardWidth, boardHeight), board),
+		),
+		container.NewHBox(check, next, widget.NewLabel("Write notes as"), accidental),
+	))
+	w.Show()
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Session history. ::: Every finished round is appended, one JSON object per line, to history.jsonl in the student's
+// profile folder (see profiles.go), so results outlive the sitting that produced them. The file is only ever appended to; the query
+// functions below read it back and total it up (accuracy by pitch, by clef, by mode).
+
+// roundRecord is one round as it is stored.
+type roundRecord struct {
+	Time    time.Time `json:"time"` // when the round ended
+	Mode    string    `json:"mode"`
+	Seed    int64     `json:"seed"`              // the seed the round was dealt from (see seed.go)
+	Round   int       `json:"round"`             // and its place in that seed's sequence
+	Targets []string  `json:"targets"`           // what the round asked for
+	Marks   []string  `json:"marks"`             // what the student answered with, in order
+	Correct []string  `json:"correct,omitempty"` // targets that were answered
+	Wrong   []string  `json:"wrong,omitempty"`   // answers that weren't targets
+	Missing []string  `json:"missing,omitempty"` // targets that were never answered
+	Seconds float64   `json:"seconds"`           // from the round being shown to its last answer
+	Hints   int       `json:"hints"`             // answers judged before the last: each one told the student what was still wrong
+	Solved  bool      `json:"solved"`
+}
+
+// scoreRound sorts a round's answers against its targets. With anyOctave a target is met by its note in any octave,
+// as when building a chord, and a missing one is recorded without an octave.
+func scoreRound(targets, marks []Pitch, anyOctave bool) (correct, wrong, missing []string) {
+	key := func(p Pitch) string {
+		if anyOctave {
+			return p.name()
+		}
+		return p.String()
+	}
+	met := map[string]bool{}
+	want := map[string]bool{}
+	for _, t := range targets {
+		want[key(t)] = true
+	}
+	for _, m := range marks {
+		if want[key(m)] {
+			correct = append(correct, m.String())
+			met[key(m)] = true
+		} else {
+			wrong = append(wrong, m.String())
+		}
+	}
+	for _, t := range targets {
+		if !met[key(t)] {
+			missing = append(missing, key(t))
+			met[key(t)] = true // a target listed twice is only missing once
+		}
+	}
+	return correct, wrong, missing
+}
+
+// historyStore is the append-only file of round records.
+type historyStore struct {
+	path string
+}
+
+// openHistory is the history kept in a profile folder.
+func openHistory(dir string) *historyStore {
+	return &historyStore{path: filepath.Join(dir, historyFileName)}
+}
+
+// append adds one record at the end of the file, as a single write so a crash can't interleave half a line.
+func (h *historyStore) append(r roundRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// load reads every record back, oldest first. A line that doesn't parse (say, the tail of a write cut short) is
+// skipped with a note on the terminal rather than losing the rest of the history.
+func (h *historyStore) load() ([]roundRecord, error) {
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var records []roundRecord
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		var r roundRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			fmt.Printf("%s line %d skipped: %v\n", h.path, n, err)
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// accuracy totals the outcomes for one pitch (or clef, or mode).
+type accuracy struct {
+	Right, Wrong, Missed int
+}
+
+// rate is the share of outcomes that were right, 0 when there are none.
+func (a accuracy) rate() float64 {
+	total := a.Right + a.Wrong + a.Missed
+	if total == 0 {
+		return 0
+	}
+	return float64(a.Right) / float64(total)
+}
+
+func (a accuracy) String() string {
+	return fmt.Sprintf("%.0f%% (%d right, %d wrong, %d missed)", 100*a.rate(), a.Right, a.Wrong, a.Missed)
+}
+
+// filterRecords keeps the records for which keep is true, e.g. one mode's, or the last week's.
+func filterRecords(records []roundRecord, keep func(roundRecord) bool) []roundRecord {
+	var kept []roundRecord
+	for _, r := range records {
+		if keep(r) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// tally runs every pitch outcome of the records through group, and totals them under the keys it returns; an
+// empty key drops the outcome.
+func tally(records []roundRecord, group func(pitch string) string) map[string]accuracy {
+	totals := map[string]accuracy{}
+	add := func(pitches []string, count func(*accuracy)) {
+		for _, p := range pitches {
+			if key := group(p); key != "" {
+				a := totals[key]
+				count(&a)
+				totals[key] = a
+			}
+		}
+	}
+	for _, r := range records {
+		add(r.Correct, func(a *accuracy) { a.Right++ })
+		add(r.Wrong, func(a *accuracy) { a.Wrong++ })
+		add(r.Missing, func(a *accuracy) { a.Missed++ })
+	}
+	return totals
+}
+
+// accuracyByPitch totals outcomes per pitch as recorded, e.g. "F#4"; chord tones missed in any octave count as "F#".
+func accuracyByPitch(records []roundRecord) map[string]accuracy {
+	return tally(records, func(p string) string { return p })
+}
+
+// accuracyByClef totals outcomes per staff, "treble" from C4 up and "bass" below it; pitches without an octave are
+// left out.
+func accuracyByClef(records []roundRecord) map[string]accuracy {
+	return tally(records, func(name string) string {
+		p, err := parsePitch(name)
+		switch {
+		case err != nil:
+			return ""
+		case p.step() >= trebleLowStep:
+			return "treble"
+		}
+		return "bass"
+	})
+}
+
+// accuracyByMode totals whole rounds per mode: a solved round is right, any other wrong.
+func accuracyByMode(records []roundRecord) map[string]accuracy {
+	totals := map[string]accuracy{}
+	for _, r := range records {
+		a := totals[r.Mode]
+		if r.Solved {
+			a.Right++
+		} else {
+			a.Wrong++
+		}
+		totals[r.Mode] = a
+	}
+	return totals
+}
+
+// weakest lists the keys of totals from the lowest accuracy up, at most n of them; ties go alphabetically.
+func weakest(totals map[string]accuracy, n int) []string {
+	var keys []string
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := totals[keys[i]].rate(), totals[keys[j]].rate()
+		if ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
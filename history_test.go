@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+// pitches parses a list of pitch names.
+func pitches(names ...string) []Pitch {
+	var ps []Pitch
+	for _, n := range names {
+		ps = append(ps, mustParsePitch(n))
+	}
+	return ps
+}
+
+func TestScoreRound(t *testing.T) {
+	tests := []struct {
+		name                    string
+		targets, marks          []Pitch
+		anyOctave               bool
+		correct, wrong, missing []string
+	}{
+		{"all found", pitches("C4", "C5"), pitches("C5", "C4"), false, []string{"C5", "C4"}, nil, nil},
+		{"one wrong, one missing", pitches("C4", "C5"), pitches("C4", "D4"), false, []string{"C4"}, []string{"D4"}, []string{"C5"}},
+		{"left unanswered", pitches("F#4", "A4"), nil, false, nil, nil, []string{"F#4", "A4"}},
+		{"a target listed twice", pitches("E4", "E4"), nil, false, nil, nil, []string{"E4"}},
+		{"a chord in any octave", pitches("C4", "E4", "G4"), pitches("C3", "G5"), true, []string{"C3", "G5"}, nil, []string{"E"}},
+		{"a chord left unanswered", pitches("D4", "F#4", "A4"), nil, true, nil, nil, []string{"D", "F#", "A"}},
+	}
+	for _, tt := range tests {
+		correct, wrong, missing := scoreRound(tt.targets, tt.marks, tt.anyOctave)
+		if !reflect.DeepEqual(correct, tt.correct) || !reflect.DeepEqual(wrong, tt.wrong) || !reflect.DeepEqual(missing, tt.missing) {
+			t.Errorf("%s: got %q, %q, %q; want %q, %q, %q", tt.name, correct, wrong, missing, tt.correct, tt.wrong, tt.missing)
+		}
+	}
+}
+
+// Records come back as they were appended, an unsolved round with nothing answered included, and a line cut short
+// is skipped without losing the rest.
+func TestHistoryStore(t *testing.T) {
+	h := openHistory(t.TempDir())
+	if records, err := h.load(); err != nil || records != nil {
+		t.Fatalf("a new history: %v, %v", records, err)
+	}
+	_, _, missing := scoreRound(pitches("G4", "G5"), nil, false)
+	unsolved := roundRecord{Mode: findTheNote.String(), Round: 1, Targets: []string{"G4", "G5"}, Missing: missing}
+	solved := roundRecord{Mode: buildScale.String(), Round: 2, Targets: []string{"D4"}, Marks: []string{"D4"}, Correct: []string{"D4"}, Solved: true}
+	if err := h.append(unsolved); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"mode": "find the no` + "\n") // a write cut short
+	f.Close()
+	if err := h.append(solved); err != nil {
+		t.Fatal(err)
+	}
+	records, err := h.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []roundRecord{unsolved, solved}; !reflect.DeepEqual(records, want) {
+		t.Errorf("loaded\n%+v\nwant\n%+v", records, want)
+	}
+	if got := accuracyByMode(records); got[findTheNote.String()] != (accuracy{Wrong: 1}) || got[buildScale.String()] != (accuracy{Right: 1}) {
+		t.Errorf("accuracy by mode: %v", got)
+	}
+	if got := accuracyByClef(records); got["treble"] != (accuracy{Right: 1, Missed: 2}) || len(got) != 1 {
+		t.Errorf("accuracy by clef: %v", got)
+	}
+}
+
+func TestWeakest(t *testing.T) {
+	totals := map[string]accuracy{
+		"C4": {Right: 9, Missed: 1},
+		"F4": {Right: 1, Wrong: 3},
+		"B3": {Right: 1, Missed: 3},
+		"A5": {Right: 5, Wrong: 5},
+	}
+	if got, want := weakest(totals, 3), []string{"B3", "F4", "A5"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
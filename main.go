@@ -1,16 +1,21 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 	"image/color"
+	"io"
 	"math"
 	"math/rand"
+	"strings"
+	"time"
 )
 
 // @formatter:off
@@ -24,12 +29,33 @@ type NotePosition struct {
 
 // MarkedNote tracks a placed note for possible retraction in case of player error; implements interface
 type MarkedNote struct {
-	Circle *canvas.Circle
-	X      float32
-	Y      float32
+	Glyph []fyne.CanvasObject // the drawn note: head, stem, and so on
+	Pitch string              // the note the mark snapped to, such as "C4"
+	X     float32
+	Y     float32
 }
 
+// gameMode is what the rounds ask the student to do.
+type gameMode int
+
+const (
+	findTheNote     gameMode = iota // click every note of a letter (or play through an imported exercise)
+	singTheNote                     // sing or play the highlighted note
+	sightReadMelody                 // name a whole melody, one note after another
+	buildChord                      // stack a chord on one staff
+	identifyChord                   // name the root, quality and inversion of a drawn chord
+	buildScale                      // place a scale, one note per degree, from its tonic up
+	transposeDrill                  // convert between concert and written pitch for the chosen instrument
+	blitz                           // find-the-note rounds against the clock, each advancing as soon as it is solved
+)
+
+// gameModeNames are how the modes are recorded in the session history.
+var gameModeNames = [...]string{"find the note", "sing the note", "sight reading", "build a chord", "identify chords", "build a scale", "transposition drill", "blitz"}
+
+func (m gameMode) String() string { return gameModeNames[m] }
+
 func main() { 
+	flag.Parse() // --seed (see seed.go)
 	about_app() // show SLOC on the terminal; and, maintain a log file: musicAppLog.txt where those LOC figures are tracked. 
 	
 	// Initialize Fyne app  -- app.___ is a Fyne object.
@@ -37,68 +63,45 @@ func main() {
 	parentWindow := RicksFirstGUI.NewWindow("Rick's Find the Note game") // create the app window and title it.
 	parentWindow.Resize(fyne.NewSize(1000, 1000)) 
 
-	// Define the Grand Staff notes (A5 to F2)
-	notes := []string{
-		"A5", "G5", "F5", "E5", "D5", "C5", "B4", "A4", "G4", "F4", "E4", // Treble
-		"D4", "C4", "B3", // Middle
-		"A3", "G3", "F3", "E3", "D3", "C3", "B2", "A2", "G2", "F2", // Bass
-	}
-	notePositions := make([]NotePosition, len(notes))
-	/*
-	make is a built-in function used to create and initialize certain built-in types: slices, maps, and channels. When
-		you see make([]Type, length), it creates a slice of type []Type with a specified length (and optionally a capacity, if provided
-		as a third argument).
-		[]NotePosition: Specifies the slice type—elements are NotePosition structs.
-		len(notes): Sets the length of the slice to 24 (since len(notes) is 24).
-		Result::: notePositions is a slice of 24 NotePosition elements (structs), pre-allocated and initialized with zero values for the
-		::: type (Pitch: "", Y: 0.0 for each element).
-	*/
-
-	// Load the empty notePositions slice:
-	// Calculate and set the Y-axis coordinates for each note to match the staff layout. We could have hardcoded each, but calculating ...
-	// ... them is both fun and less error-prone!
-	for i, note := range notes { // "i" will become 0 through 23
-		if i < 11 { // for the first 11 lines/notes, Treble (A5 to E4), calculate and assign each note its position on the Y-axis.
-			notePositions[i] = NotePosition{Pitch: note, Y: float32(40 + i*30)} // here "i" is 0 for the first iteration...
-			// ... e.g., when i=0, Y=40 A5; i=1, Y=70 G5, i=2, Y=100 F5
-			// when i=3, 40+(3*30)=130 E5 -- i=4 160 D5 -- i=5 190 C5 -- i=6 220 B4 -- i=7 250 A4 -- i=8 280 G4 -- i=9 310 F4 -- i=10 Y= 340 E4
-		} else if i < 13 { // for the next two lines/notes, (D4 and C4), calculate and assign their positions on the Y-axis.
-			notePositions[i] = NotePosition{Pitch: note, Y: float32(370 + (i-11)*30)}
-		} else if i == 13 { // the Y-axis of the B3 note/line is unique...
-			notePositions[i] = NotePosition{Pitch: note, Y: 490} // ... so this one gets hardcoded as Y = 490
-		} else { // the remaining notes on the Bass clef, (A3 to F2), are calculated with respect to the iterated value of "i", thusly:
-			notePositions[i] = NotePosition{Pitch: note, Y: float32(520 + (i-14)*30)}
+	// The Grand Staff notes (A5 to F2), and where each sits on the Y-axis: see grandStaff.go
+	notes := grandStaffNotes
+	notePositions := newNotePositions()
+
+	// ::: Every random choice of the game comes from random, so a sitting replays from its seed (see seed.go).
+	seed := sessionSeed(*seedFlag, time.Now())
+	random := newGameRandom(seed, rand.NewSource(seed))
+	random.nextRound() // the first round is dealt right here
+	fmt.Printf("Seed %d (replay this sitting with --seed %d)\n", random.Seed, random.Seed)
+	// The guitar and rhythm windows deal from random numbers of their own, seeded from the sitting's, so opening them
+	// doesn't shift the game's rounds and a replay deals them the same drills.
+	guitarRand, rhythmRand := rand.New(rand.NewSource(seed+1)), rand.New(rand.NewSource(seed+2))
+	daily := false // the rounds are the Daily Challenge's: the same for everyone today
+
+	// ::: Levels and streaks (see progress.go) decide which staff positions random find-the-note rounds may ask for.
+	// Like the session history, they belong to the student whose profile is in use (see profiles.go, and useProfile).
+	var prog progress
+	progressFile := "" // nothing is saved until a profile is in use
+	saveProgress := func() {
+		if progressFile == "" {
+			return
+		}
+		if err := prog.save(progressFile); err != nil {
+			fmt.Println("Couldn't save progress:", err)
 		}
-		/*
-					Results:
-					i=0:  {Pitch: "A5", Y: 40}
-			... see above for i=1 to i=9
-					i=10: {Pitch: "E4", Y: 340}
-					i=11: {Pitch: "D4", Y: 370}
-					i=12: {Pitch: "C4", Y: 400}
-
-					i=13: {Pitch: "B3", Y: 490}
-					i=14: {Pitch: "A3", Y: 520}
-					i=23: {Pitch: "F2", Y: 790}
-
-				or:
-
-					notePositions[0] = {Pitch: "A5", Y: 40}
-					notePositions[1] = {Pitch: "G5", Y: 70}
-					...
-					notePositions[23] = {Pitch: "F2", Y: 790}
-		*/
 	}
+	levelRound := true   // the round is a random find-the-note one, which counts toward the level
+	targetAccidental := 0 // the sharp (1) or flat (-1) every target carries, at the levels with accidentals
+	levelAccidentals := false // the round is at such a level, so placed notes take the sharp or flat chosen
 
 	// Pick a note, randomly, for the player to place at each of its proper locations on the Grand Staff
-	targetNoteLetter := []string{"C", "D", "E", "F", "G", "A", "B"}[rand.Intn(7)] // [rand.Intn(7)] uses a random number as index to the slice.
+	targetNoteLetter := []string{"C", "D", "E", "F", "G", "A", "B"}[random.Intn(7)] // [random.Intn(7)] uses a random number as index to the slice.
 	var targetPositions []NotePosition  // NotePosition is a custom type, and targetPositions is then a new empty slice of those types.
 	// was: targetPositions := []NotePosition{} // NotePosition is a custom type, and targetPositions is then a new empty slice of those types.
 	// var is just a declaration (nil slice), while := initializes an empty slice. Functionally identical here since we append right away.
 	for _, pos := range notePositions { // notePositions is a slice of 24 NotePosition elements (structs); each now loaded with a ...
 		// ... Y coordinate. ::: "pos" is therefore a struct of type NotePosition; each containing one of those Y-axis coordinate values which
 		// .. was calculated above. And, we toss the unneeded range position via the built-in _ bit bin variable -- “blank identifier” (Go term for _).
-		if pos.Pitch[0:1] == targetNoteLetter { // targetNoteLetter could be any of C to B, as per the randomly indexed slice above. And ...
+		if pos.Pitch[0:1] == targetNoteLetter && levels[prog.Level].includes(pos) { // targetNoteLetter could be any of C to B, as per the randomly indexed slice above. And ...
 			/*
 			pos could be, e.g., {Pitch: "A5", Y: 40}  pos.Pitch returns the Pitch field: "A5"; Whereas pos.Y would return the Y field: 40.
 			pos.Pitch is a string like "A5". And [0:1]: is a slice expression applied to that string. In Go, strings are sliceable, meaning that
@@ -125,58 +128,100 @@ func main() {
 	*/
 	fmt.Printf("Target %s notes: %v\n", targetNoteLetter, targetPositions) // log activity to the console/terminal.
 
-	// Create canvas for the staff: canvas.___ is a fyne object. Compare Fyne calls near top of main.
-	staffCanvas := canvas.NewRectangle(&color.RGBA{R: 25, G: 200, B: 25, A: 155})
-	staffCanvas.Resize(fyne.NewSize(1000, 1000)) // Needed to apply the colors specified on the previous line; default is very dark grey. 
-
-	// Draw the Grand Staff
-	lines := []fyne.CanvasObject{staffCanvas}
-	// Treble staff (E4 bottom, F5 top)
-	for i := 0; i < 5; i++ {
-		y := float32(340 - i*60) // E4 (340), G4 (280), B4 (220), D5 (160), F5 (100) // F5 at 100 ?? i=9 Y=310  -- i=9 310 F5 -- 
-		// when i=4, 370 - i*60 = 130
-		line := canvas.NewLine(&color.Black)
-		line.Position1 = fyne.NewPos(100, y)
-		line.Position2 = fyne.NewPos(900, y)
-		line.StrokeWidth = 2
-		lines = append(lines, line)
-	}
-
-	// Bass staff (G2 bottom, A3 top)
-	for i := 0; i < 5; i++ {
-		y := float32(760 - i*60) // G2 (760), B2 (700), D3 (640), F3 (580), A3 (520)
-		line := canvas.NewLine(&color.Black)
-		line.Position1 = fyne.NewPos(100, y)
-		line.Position2 = fyne.NewPos(900, y)
-		line.StrokeWidth = 2
-		lines = append(lines, line)
-	}
-	// Middle C ledger line (C4)
-	for x := 400; x < 600; x += 20 {
-		ledger := canvas.NewLine(&color.Black)
-		ledger.Position1 = fyne.NewPos(float32(x), 400)
-		ledger.Position2 = fyne.NewPos(float32(x+10), 400)
-		ledger.StrokeWidth = 2
-		lines = append(lines, ledger)
-	}
-	// A5 ledger line (above G5)
-	for x := 400; x < 600; x += 20 {
-		ledger := canvas.NewLine(&color.Black)
-		ledger.Position1 = fyne.NewPos(float32(x), 40)
-		ledger.Position2 = fyne.NewPos(float32(x+10), 40)
-		ledger.StrokeWidth = 2
-		lines = append(lines, ledger)
-	}
+	// Draw the Grand Staff (see grandStaff.go; the stats window draws the very same one)
+	lines := drawGrandStaff(&color.RGBA{R: 25, G: 200, B: 25, A: 155})
 
 	// ::: Track the player's marked notes — places where they’ve placed circles/dots.
 	markedNotes := []MarkedNote{} // empty slice declaration using literal {}
 	// could also have done a: var markedNotes []MarkedNote // var is just a declaration (nil slice), while := initializes an empty slice.
 	// it’s for storing MarkedNote structs from clicks.
 
+	// ::: An exercise imported from a MIDI file is played one note per round, before falling back to random letters.
+	var exercise []exerciseRound
+	exerciseName := ""
+	exerciseIndex := 0
+	var shownNote []fyne.CanvasObject // the note drawn for a name-the-note or sing round; nil otherwise
+	var keySigObjects []fyne.CanvasObject // the transposition drill's key signature, which leaves the staff open to marks
+	mode := findTheNote
+	chordSevenths := false   // chord rounds ask for seventh chords rather than triads
+	var chordTarget chordRound
+	chordQuizLevel := 0 // 0: close triads, 1: close triads and sevenths, 2: open voicings across both staves
+	var quizChord chord
+	var scaleTarget scale
+	var drill transpositionRound
+	// Scale rounds need sharps and flats: the chosen accidental goes on each note clicked onto the staff.
+	accidentalChoice := widget.NewRadioGroup([]string{"Natural", "Sharp (#)", "Flat (b)"}, nil)
+	accidentalChoice.Horizontal = true
+	accidentalChoice.Required = true
+	accidentalChoice.SetSelected("Natural")
+	accidentalBox := container.NewHBox(widget.NewLabel("Place notes as"), accidentalChoice)
+	sightOptions := defaultSightReadingOptions
+	var reading *sightReading // the melody being read in sight-reading mode; nil otherwise
+	var readingCursor *canvas.Line
+
+	// ::: The staff starts as one free column of notes; Settings > Measures divides it into bars with beat slots.
+	staffLayout := newGrandStaffLayout(0, commonTimeSignatures[2])
+	var layoutObjects []fyne.CanvasObject // barlines and time signatures, kept just above the staff lines
+
 	// Create staff container (a fyne object to hold staff lines and notes)
 	staffContainer := container.NewWithoutLayout(lines...)
 	// No Resize/Move statement for staffContainer — VBox in content overrides these!
 
+	// ::: The on-screen piano keyboard below the staff: clicking a key plays it like a MIDI keyboard does (see playNote),
+	// and the keys of the marked notes light up.
+	var playNote func(p Pitch)
+	keyboard := newPianoKeyboard(mustParsePitch(notes[len(notes)-1]), mustParsePitch(notes[0]), func(p Pitch) { withGame(func() { playNote(p) }) })
+	refreshKeyboard := func() {
+		var marked []Pitch
+		for _, mark := range markedNotes { // the staff shows written pitch, the keys sound concert pitch
+			marked = append(marked, currentInstrument.concert(mustParsePitch(mark.Pitch)))
+		}
+		keyboard.highlight(marked)
+	}
+
+	// ::: What happens in the game is published on events (see events.go) for features to subscribe to.
+	events := &eventBus{}
+	events.subscribe(logEvent)
+	events.subscribe(func(e gameEvent) { // the keys light up with whatever is marked on the staff
+		switch e.(type) {
+		case NoteMarked, NoteRemoved, RoundStarted:
+			refreshKeyboard()
+		}
+	})
+	achievementsEarned := &achievementTracker{OnUnlock: func(a achievement) {
+		showToast(parentWindow.Canvas(), "Achievement unlocked: "+a.Name)
+	}}
+	events.subscribe(achievementsEarned.observe)
+
+	// removeMark takes the i-th marked note back off the staff.
+	removeMark := func(i int) {
+		note := markedNotes[i]
+		for _, obj := range note.Glyph {
+			staffContainer.Remove(obj)
+		}
+		markedNotes = append(markedNotes[:i], markedNotes[i+1:]...)
+		staffContainer.Refresh()
+		events.publish(NoteRemoved{Pitch: mustParsePitch(note.Pitch), X: note.X, Y: note.Y})
+	}
+
+	// markNote puts a red note on the staff at pos, centered on noteX and raised or lowered by accidental; used by staff
+	// clicks and by MIDI keyboard input alike.
+	markNote := func(pos NotePosition, noteX float32, accidental int) {
+		// Draw a red quarter note, its stem following the middle line of its staff
+		red := &color.RGBA{R: 255, G: 0, B: 0, A: 255}
+		glyph := drawStaffNote(pos, noteX, red)
+		pitch := mustParsePitch(pos.Pitch)
+		if pitch.Accidental = accidental; accidental != 0 {
+			glyph = append(glyph, drawAccidental(accidental, noteX, pos.Y, grandStaffMetrics, red))
+		}
+		markedNotes = append(markedNotes, MarkedNote{Glyph: glyph, Pitch: pitch.String(), X: noteX, Y: pos.Y})
+		for _, obj := range glyph {
+			staffContainer.Add(obj)  // Add replaces deprecated AddObject—keeps it modern!
+		}
+		staffContainer.Refresh()   // staffContainer is an instance of container.NewWithoutLayout(lines...) , done above.
+		events.publish(NoteMarked{Pitch: pitch, X: noteX, Y: pos.Y}) // logged to the terminal by logEvent
+	}
+
 	// Handle mouse clicks with a tappable rectangle (more fyne objects)
 	staffArea := canvas.NewRectangle(&color.Transparent) // invisible overlay for detecting mouse clicks
 
@@ -235,20 +280,22 @@ func main() {
 		// CanvasObject: staffArea, Embeds staffArea (a transparent rectangle) as the drawable CanvasObject — makes it tappable and visible
 		OnTapped: func(e *fyne.PointEvent) { // OnTapped is the callback func "from" the TappableCanvas struct which is an extended instance of CanvasObject.
 			// ... It sets OnTapped, the tap-handling callback in TappableCanvas — extending CanvasObject with our click magic!
+			gameMu.Lock() // taps run on Fyne's goroutine, MIDI notes and the blitz clock on theirs (see clock.go)
+			defer gameMu.Unlock()
+			if shownNote != nil { // name-the-note rounds are answered with the letter buttons, not by marking the staff
+				return
+			}
 			clickX, clickY := e.Position.X, e.Position.Y // e is the argument passed to the OnTapped callback func that we are defining here. 
 			// e is a *fyne.PointEvent, a struct with fields like Position (a fyne.Position with X and Y floats). It’s the event data—where the player clicked.
 			// e.Position.X and e.Position.Y extract the click coordinates. e is the tap event (*fyne.PointEvent) — grabs X/Y coords
 			
 			// Check if clicking an existing note to remove it
 			for i, note := range markedNotes {
-				dx := clickX - note.X
-				dy := clickY - note.Y
+				dx := (clickX - note.X) / (grandStaffMetrics.HeadW / 2)
+				dy := (clickY - note.Y) / (grandStaffMetrics.HeadH / 2)
 				distance := float32(math.Sqrt(float64(dx*dx + dy*dy)))
-				if distance < 10 { // ::: Within 10px radius of note center
-					staffContainer.Remove(note.Circle)
-					markedNotes = append(markedNotes[:i], markedNotes[i+1:]...)
-					staffContainer.Refresh()
-					fmt.Printf("Removed note at X=%.0f, Y=%.0f\n", note.X, note.Y)
+				if distance < 1 { // ::: Inside the oval of the note head
+					removeMark(i)
 					return
 				}
 			}
@@ -268,43 +315,217 @@ func main() {
 			This is a classic “nearest neighbor” algorithm—simple yet effective. It’s forgiving (no threshold—always snaps), but 
 			you could add if minDiff < 20 to limit snapping range if desired.
 		*/
-			// Determine X position: ledger (center) or staff (right)
-			var noteX float32
-
-			if closest.Pitch == "A5" || closest.Pitch == "C4" {
-				noteX = 500 // Center of ledger lines (400-600)
-			} else {
-				noteX = 300 // Halfway between staff left (100) and ledger left (400)
+			// X positioning: the ledger (center) or the staff (left) in a free layout, otherwise the beat slot nearest the click
+			noteX := noteXFor(closest.Pitch)
+			accidental := 0
+			switch {
+			case mode == buildChord: // a chord is stacked in a single column
+				noteX = chordColumnX
+				accidental = map[string]int{"Sharp (#)": 1, "Flat (b)": -1}[accidentalChoice.Selected]
+			case mode == buildScale: // a scale runs left to right, each note in the first free column
+				noteX = scaleNoteX(markedNotes, len(scaleTarget.notes()))
+				accidental = map[string]int{"Sharp (#)": 1, "Flat (b)": -1}[accidentalChoice.Selected]
+			case mode == transposeDrill:
+				accidental = map[string]int{"Sharp (#)": 1, "Flat (b)": -1}[accidentalChoice.Selected]
+			case !staffLayout.free():
+				noteX = staffLayout.slotX(staffLayout.snap(clickX))
 			}
-
-			// Add a red circle/dot
-			circle := canvas.NewCircle(&color.RGBA{R: 255, G: 0, B: 0, A: 255})
-			circle.Resize(fyne.NewSize(20, 20)) // Needed to apply the colors specified on the previous line; default appears to be invisible ???
-			circle.Move(fyne.NewPos(noteX-10, closest.Y-10)) // Center circle on position
-			markedNotes = append(markedNotes, MarkedNote{Circle: circle, X: noteX, Y: closest.Y})
-			staffContainer.Add(circle)  // Add replaces deprecated AddObject—keeps it modern!
-			staffContainer.Refresh()   // staffContainer is an instance of container.NewWithoutLayout(lines...) , done above.
-			fmt.Printf("Marked %s at X=%.0f, Y=%.0f\n", closest.Pitch, noteX, closest.Y) // debugging log to terminal.
+			if levelAccidentals {
+				accidental = map[string]int{"Sharp (#)": 1, "Flat (b)": -1}[accidentalChoice.Selected]
+			}
+			markNote(closest, noteX, accidental)
 		},
 	}
 	staffContainer.Add(staffAreaTapped) // staffContainer is an instance of container.NewWithoutLayout(lines...) , done above. 
+	keyboard.Object.Move(fyne.NewPos((1000-keyboard.Object.Size().Width)/2, 820)) // centered under the bass staff, clear of F2
+	staffContainer.Add(keyboard.Object)
 	// Adds our tappable layer to staffContainer (from NewWithoutLayout above) — clicks live here!
 
 	// Instruction and feedback
 	instruction := widget.NewLabel(fmt.Sprintf("Click all %s notes on the Grand Staff", targetNoteLetter))
 	instruction.TextStyle = fyne.TextStyle{Bold: true}
+	progressLabel := widget.NewLabel(prog.header()) // the level and streak, above the instruction
 	// No Resize statement for instruction — VBox sets size based on text!
 
 	feedback := canvas.NewText("", color.Black)
 	feedback.TextSize = 24
 	feedback.TextStyle = fyne.TextStyle{Bold: true}
 
+	// ::: Blitz mode (see blitz.go): a countdown, shown on a progress bar, while the rounds advance by themselves.
+	blitzBar := widget.NewProgressBar()
+	blitzBar.Max = blitzLength.Seconds()
+	blitzBar.TextFormatter = func() string { return fmt.Sprintf("%.0fs left", blitzBar.Value) }
+	blitzBar.Hide()
+	var score blitzScore
+	var timer *countdown
+	var blitzClock clock = realClock{}
+	var blitzRoundStart time.Time
+	lastScored := "" // what the latest mark earned or cost, shown next to the score
+
 	// Define content container
 	content := container.NewVBox()
 
+	// ::: Keep every checked round of this sitting so the whole practice session can be exported as a .mid file.
+	roundNumber := 1
+	var session []exportRound
+	recordRound := func() { // a re-Check of the same round replaces its earlier snapshot
+		round := newExportRound(roundNumber, mode, targetNoteLetter, targetPositions, markedNotes)
+		if len(session) > 0 && session[len(session)-1].Number == roundNumber {
+			session[len(session)-1] = round
+		} else {
+			session = append(session, round)
+		}
+	}
+
+	markedPitches := func() []Pitch {
+		var marks []Pitch
+		for _, mark := range markedNotes {
+			marks = append(marks, mustParsePitch(mark.Pitch))
+		}
+		return marks
+	}
+
+	// targetPitches are the round's targets as pitches, with the sharp or flat the level put on them.
+	targetPitches := func() []Pitch {
+		var targets []Pitch
+		for _, target := range targetPositions {
+			p := mustParsePitch(target.Pitch)
+			p.Accidental = targetAccidental
+			targets = append(targets, p)
+		}
+		return targets
+	}
+
+	// ::: Every round, in every mode, also goes into the session history on disk (see history.go). A round's latest
+	// result is kept in pending and only written out once the round is over: when the next one starts, or the app closes.
+	// A round that was never answered (skipped with New Round, or left on screen) is written out too, as unsolved, with
+	// everything it asked for missing.
+	var history *historyStore // nil until a profile is in use
+	roundShown := time.Now()
+	var pending *roundRecord
+	roundOver := true // the round on screen has been written out; the one set up above waits for useProfile to deal it
+	newRecord := func(targets, marks []Pitch, anyOctave, solved bool) roundRecord {
+		r := roundRecord{Mode: mode.String(), Seed: random.Seed, Round: random.Round, Seconds: time.Since(roundShown).Seconds(), Solved: solved}
+		for _, t := range targets {
+			r.Targets = append(r.Targets, t.String())
+		}
+		for _, m := range marks {
+			r.Marks = append(r.Marks, m.String())
+		}
+		r.Correct, r.Wrong, r.Missing = scoreRound(targets, marks, anyOctave)
+		return r
+	}
+	noteResult := func(targets, marks []Pitch, anyOctave, solved bool) {
+		r := newRecord(targets, marks, anyOctave, solved)
+		if pending != nil { // the earlier answer was judged and told the student what was wrong: a hint
+			r.Hints = pending.Hints + 1
+		}
+		pending = &r
+		events.publish(RoundChecked{Record: r})
+	}
+	// asked is what the round on screen asks for, as its mode hands it to noteResult.
+	asked := func() (targets []Pitch, anyOctave bool) {
+		switch {
+		case reading != nil:
+			return reading.Melody, false
+		case mode == transposeDrill && drill.ToConcert:
+			return []Pitch{drill.Concert}, false
+		case mode == transposeDrill:
+			return []Pitch{drill.Written}, false
+		case mode == buildScale:
+			return scaleTarget.notes(), false
+		case mode == buildChord:
+			return chordTarget.Chord.tones(), true
+		case mode == identifyChord:
+			return quizChord.tones(), true
+		case len(targetPositions) == 0 && exerciseIndex > 0 && exerciseIndex <= len(exercise): // naming an exercise's note
+			return []Pitch{mustParsePitch(exercise[exerciseIndex-1].staffPitch())}, false
+		}
+		return targetPitches(), false
+	}
+	flushRound := func() {
+		if roundOver {
+			return
+		}
+		if pending == nil {
+			targets, anyOctave := asked()
+			r := newRecord(targets, nil, anyOctave, false)
+			pending = &r
+		}
+		pending.Time = time.Now()
+		done := *pending
+		pending, roundOver = nil, true
+		events.publish(RoundCompleted{Record: done})
+	}
+	events.subscribe(func(e gameEvent) { // finished rounds go into the history of the profile in use
+		if c, ok := e.(RoundCompleted); ok && history != nil {
+			if err := history.append(c.Record); err != nil {
+				fmt.Println("Couldn't save the round:", err)
+			}
+		}
+	})
+
 	// Check button — tallies player’s note placements.
 	var checkButton *widget.Button
+	chordAnswer := newChordSelectors()
 	checkButton = widget.NewButton("Check", func() {
+		gameMu.Lock()
+		defer gameMu.Unlock()
+		if mode == transposeDrill { // the written note, spelled as the written key spells it
+			msg, ok := judgeTransposition(drill, currentInstrument, markedPitches())
+			noteResult([]Pitch{drill.Written}, markedPitches(), false, ok)
+			if ok {
+				checkButton.Disable()
+			}
+			fmt.Println(msg)
+			recordRound()
+			feedback.Text = msg
+			feedback.Refresh()
+			return
+		}
+		if mode == buildScale {
+			var placed []Pitch
+			for _, mark := range markedNotes {
+				placed = append(placed, mustParsePitch(mark.Pitch))
+			}
+			msg, ok := judgeScale(scaleTarget, placed)
+			noteResult(scaleTarget.notes(), placed, false, ok)
+			if ok {
+				checkButton.Disable()
+			}
+			fmt.Println(msg)
+			recordRound()
+			feedback.Text = msg
+			feedback.Refresh()
+			return
+		}
+		if mode == identifyChord {
+			msg, ok := chordAnswer.judge(quizChord)
+			named := []Pitch(nil) // a chord named right counts all its tones as answered
+			if ok {
+				named = quizChord.tones()
+			}
+			noteResult(quizChord.tones(), named, true, ok)
+			if ok {
+				checkButton.Disable()
+			}
+			fmt.Println(msg)
+			feedback.Text = msg
+			feedback.Refresh()
+			return
+		}
+		if mode == buildChord { // chord rounds are scored as a set of tones, not against target positions
+			msg, ok := judgeChord(chordTarget, markedNotes)
+			noteResult(chordTarget.Chord.tones(), markedPitches(), true, ok)
+			if ok {
+				checkButton.Disable()
+			}
+			fmt.Println(msg)
+			recordRound()
+			feedback.Text = msg
+			feedback.Refresh()
+			return
+		}
 		correctCount := 0
 		wrongCount := 0
 		coveredTargets := make(map[float32]bool) // Track unique target Y positions hit
@@ -324,7 +545,7 @@ func main() {
 		for _, mark := range dedupedMarks {
 			isCorrect := false
 			for _, target := range targetPositions {
-				if abs(mark.Y-target.Y) < 15 && !coveredTargets[target.Y] {
+				if abs(mark.Y-target.Y) < 15 && !coveredTargets[target.Y] && mustParsePitch(mark.Pitch).Accidental == targetAccidental {
 					isCorrect = true
 					coveredTargets[target.Y] = true // Mark this target as covered
 					break
@@ -343,48 +564,671 @@ func main() {
 			msg = fmt.Sprintf("Perfect! All %s notes found!", targetNoteLetter) // Success message to player.
 			checkButton.Disable()
 		}
+		var marks []Pitch
+		for _, mark := range dedupedMarks {
+			marks = append(marks, mustParsePitch(mark.Pitch))
+		}
+		perfect := missing == 0 && wrongCount == 0
+		noteResult(targetPitches(), marks, false, perfect)
+		if levelRound && mode == findTheNote {
+			if prog.checked(perfect) {
+				msg = fmt.Sprintf("%s Level up! Now: %s", msg, levels[prog.Level].Name)
+			}
+			saveProgress()
+			progressLabel.SetText(prog.header())
+		}
 		fmt.Println(msg)
+		recordRound()
 		feedback.Text = msg
 		feedback.Refresh()
 		content.Refresh()
 	})
 	// No Resize statement for checkButton — HBox in content dictates button size!
 	
-	// Reset button (aka New Game) — wipes slate clean for a fresh challenge.
-	resetButton := widget.NewButton("New Game", func() {
-		targetNoteLetter = []string{"C", "D", "E", "F", "G", "A", "B"}[rand.Intn(7)]
-		targetPositions = []NotePosition{}
-		for _, pos := range notePositions {
-			if pos.Pitch[0:1] == targetNoteLetter {
-				targetPositions = append(targetPositions, pos)
+	// Letter buttons answer name-the-note rounds; they stay hidden the rest of the time.
+	letterButtons := container.NewHBox()
+	letterAnswered := false
+	// answerReading takes the next note of a sight-reading melody and moves the cursor along on a right answer.
+	answerReading := func(p Pitch, letterOnly bool) {
+		if reading.done() {
+			return
+		}
+		if !reading.answer(p, letterOnly, time.Now()) {
+			feedback.Text = fmt.Sprintf("Not %s; note %d is still waiting", p.Letter, reading.Cursor+1)
+		} else if reading.done() {
+			misread := map[int]bool{}
+			for _, i := range reading.Errors {
+				misread[i] = true
 			}
+			var firstTime []Pitch // the notes read right at the first try
+			for i, p := range reading.Melody {
+				if !misread[i] {
+					firstTime = append(firstTime, p)
+				}
+			}
+			noteResult(reading.Melody, firstTime, false, len(reading.Errors) == 0)
+			feedback.Text = reading.report()
+			fmt.Println(feedback.Text)
+			for _, b := range letterButtons.Objects {
+				b.(*widget.Button).Disable()
+			}
+			readingCursor.Hide()
+		} else {
+			feedback.Text = ""
+			x := melodyX(reading.Cursor, len(reading.Melody))
+			readingCursor.Position1.X, readingCursor.Position2.X = x, x
+			readingCursor.Refresh()
+		}
+		feedback.Refresh()
+	}
+	answerLetter := func(letter string) {
+		if reading != nil {
+			answerReading(Pitch{Letter: letter}, true)
+			return
+		}
+		if shownNote == nil || letterAnswered || mode != findTheNote { // only name-the-note exercise rounds take letters here
+			return
+		}
+		round := exercise[exerciseIndex-1]
+		msg := fmt.Sprintf("No, that's not %s. Try again!", letter)
+		named := mustParsePitch(round.staffPitch()) // the answer, at the shown note's octave
+		named.Letter = letter
+		noteResult([]Pitch{mustParsePitch(round.staffPitch())}, []Pitch{named}, false, letter == round.Pitch.Letter)
+		if letter == round.Pitch.Letter {
+			msg = fmt.Sprintf("Correct! That's %s", round.staffPitch())
+			letterAnswered = true
+			for _, b := range letterButtons.Objects {
+				b.(*widget.Button).Disable()
+			}
+		}
+		fmt.Println(msg)
+		feedback.Text = msg
+		feedback.Refresh()
+	}
+	for _, letter := range noteLetters {
+		letter := letter
+		letterButtons.Add(widget.NewButton(letter, locked(func() { answerLetter(letter) })))
+	}
+	letterButtons.Hide()
+	chordAnswer.Box.Hide()
+	accidentalBox.Hide()
+
+	// newRound sets up the next round: the next note of an imported exercise if there is one, otherwise a random letter.
+	newRound := func() {
+		flushRound()
+		roundOver = false
+		roundShown = time.Now()
+		roundNumber++
+		random.nextRound()
+		if mode != blitz && timer != nil { // left the blitz for another mode before the time was up
+			timer.Stop()
+			timer = nil
+			blitzBar.Hide()
+		}
+		if mode != findTheNote || exerciseIndex < len(exercise) { // another mode, or an imported exercise, ends the challenge
+			daily = false
 		}
-		fmt.Printf("Target %s notes: %v\n", targetNoteLetter, targetPositions)
 		for _, mark := range markedNotes {
-			staffContainer.Remove(mark.Circle)
+			for _, obj := range mark.Glyph {
+				staffContainer.Remove(obj)
+			}
 		}
 		markedNotes = []MarkedNote{}
-		instruction.SetText(fmt.Sprintf("Click all %s notes on the Grand Staff", targetNoteLetter))
-		feedback.Text = ""
+		for _, obj := range shownNote {
+			staffContainer.Remove(obj)
+		}
+		for _, obj := range keySigObjects {
+			staffContainer.Remove(obj)
+		}
+		shownNote, keySigObjects = nil, nil
+		letterButtons.Hide()
+		chordAnswer.Box.Hide()
+		accidentalBox.Hide()
 		checkButton.Enable()
+		reading = nil
+		levelRound, targetAccidental, levelAccidentals = false, 0, false
+
+		switch {
+		case mode == buildChord:
+			exercise = nil
+			chordTarget = randomChordRound(random.Rand, chordSevenths)
+			targetNoteLetter = chordTarget.Chord.symbol()
+			targetPositions = nil
+			accidentalChoice.SetSelected("Natural")
+			accidentalBox.Show()
+			instruction.SetText(fmt.Sprintf("Build %s on the %s staff, then Check", chordTarget.Chord, chordTarget.staffName()))
+		case mode == transposeDrill:
+			exercise = nil
+			rng := random.Rand
+			drill = randomTranspositionRound(rng, currentInstrument, mustParsePitch(notes[len(notes)-1]), mustParsePitch(notes[0]))
+			targetNoteLetter = drill.Written.String()
+			targetPositions = nil
+			keySigObjects, shownNote = drawTranspositionRound(notePositions, drill, grandStaffLeft)
+			if drill.ToConcert {
+				checkButton.Disable()
+				instruction.SetText(fmt.Sprintf("%s, in %s: play the blue note's concert pitch on the piano keys", currentInstrument.Name, drill.WrittenKey))
+			} else {
+				accidentalChoice.SetSelected("Natural")
+				accidentalBox.Show()
+				instruction.SetText(fmt.Sprintf("Concert %s, in %s: place it as written for %s, then Check", drill.Concert, drill.ConcertKey, currentInstrument.Name))
+			}
+			for _, obj := range keySigObjects {
+				staffContainer.Add(obj)
+			}
+			for _, obj := range shownNote {
+				staffContainer.Add(obj)
+			}
+		case mode == buildScale:
+			exercise = nil
+			rng := random.Rand
+			treble := rng.Intn(2) == 0
+			scaleTarget = randomScale(rng, treble)
+			notes := scaleTarget.notes()
+			targetNoteLetter = scaleTarget.String()
+			targetPositions = nil
+			accidentalChoice.SetSelected("Natural")
+			accidentalBox.Show()
+			staff := "bass"
+			if treble {
+				staff = "treble"
+			}
+			instruction.SetText(fmt.Sprintf("Build the %s scale on the %s staff, from %s up to %s, then Check",
+				scaleTarget, staff, notes[0], notes[len(notes)-1]))
+		case mode == identifyChord:
+			exercise = nil
+			rng := random.Rand
+			quizChord = randomQuizChord(rng, chordQuizLevel > 0)
+			targetNoteLetter = quizChord.symbol()
+			targetPositions = nil
+			shownNote = drawChord(notePositions, voiceChord(quizChord, chordQuizLevel == 2, rng.Intn(2) == 0), chordColumnX, color.Black)
+			for _, obj := range shownNote {
+				staffContainer.Add(obj)
+			}
+			chordAnswer.reset()
+			chordAnswer.Box.Show()
+			instruction.SetText("Name this chord: its root, quality and inversion, then Check")
+		case mode == sightReadMelody:
+			exercise = nil
+			reading = newSightReading(generateMelody(random.Rand, sightOptions), time.Now())
+			targetNoteLetter = "melody"
+			targetPositions = nil
+			x := melodyX(0, len(reading.Melody))
+			readingCursor = newStroke(&color.RGBA{R: 255, G: 0, B: 0, A: 160}, x, 20, x, 800, 4)
+			shownNote = append(drawMelody(notePositions, reading.Melody, color.Black), readingCursor)
+			for _, obj := range shownNote {
+				staffContainer.Add(obj)
+			}
+			for _, b := range letterButtons.Objects {
+				b.(*widget.Button).Enable()
+			}
+			letterButtons.Show()
+			checkButton.Disable()
+			instruction.SetText("Name each note under the red cursor, left to right (letter buttons, keys A-G, or a MIDI keyboard)")
+		case mode == singTheNote:
+			exercise = nil
+			pos := notePositions[random.Intn(len(notePositions))]
+			targetNoteLetter = pos.Pitch
+			targetPositions = []NotePosition{pos}
+			shownNote = drawStaffNote(pos, noteXFor(pos.Pitch), &color.RGBA{R: 0, G: 160, B: 0, A: 255})
+			for _, obj := range shownNote {
+				staffContainer.Add(obj)
+			}
+			checkButton.Disable()
+			instruction.SetText("Sing or play the green note, then load your recording (Input > Answer from WAV Recording)")
+		case exerciseIndex < len(exercise):
+			round := exercise[exerciseIndex]
+			exerciseIndex++
+			progress := fmt.Sprintf("(note %d of %d from %s)", exerciseIndex, len(exercise), exerciseName)
+			pos, _ := findNotePosition(notePositions, round.Pitch.natural().String()) // fitToRange already kept the note on the staff
+			targetNoteLetter = round.Pitch.String()
+			switch round.Kind {
+			case placeNoteExercise:
+				targetPositions = []NotePosition{pos}
+				if round.Pitch.Accidental != 0 { // placed with the sharp or flat chosen, as at the levels with accidentals
+					targetAccidental, levelAccidentals = round.Pitch.Accidental, true
+					accidentalChoice.SetSelected("Natural")
+					accidentalBox.Show()
+				}
+				instruction.SetText(fmt.Sprintf("Place %s on the Grand Staff %s", targetNoteLetter, progress))
+			case nameNoteExercise:
+				targetPositions = nil
+				blue := &color.RGBA{R: 0, G: 0, B: 255, A: 255}
+				shownNote = drawStaffNote(pos, noteXFor(pos.Pitch), blue)
+				if round.Pitch.Accidental != 0 {
+					shownNote = append(shownNote, drawAccidental(round.Pitch.Accidental, noteXFor(pos.Pitch), pos.Y, grandStaffMetrics, blue))
+				}
+				for _, obj := range shownNote {
+					staffContainer.Add(obj)
+				}
+				for _, b := range letterButtons.Objects {
+					b.(*widget.Button).Enable()
+				}
+				letterButtons.Show()
+				letterAnswered = false
+				checkButton.Disable()
+				instruction.SetText(fmt.Sprintf("Name the blue note %s", progress))
+			}
+		default:
+			exercise = nil
+			var records []roundRecord // what the strategy learns the student's weak notes from (Settings > Target Selection)
+			if currentStrategy.usesHistory() && !daily && history != nil {
+				var err error
+				if records, err = history.load(); err != nil {
+					fmt.Println("Couldn't read the session history:", err)
+				}
+			}
+			strategy := currentStrategy
+			if daily { // the history differs from student to student; the challenge mustn't
+				strategy = uniformTargets{}
+			}
+			targetNoteLetter = strategy.pick(random.Rand, noteLetters, records)
+			lv := levels[prog.Level]
+			levelRound = !daily
+			if daily { // the same rounds for everyone, whatever their level
+				lv = levels[dailyLevel(random.Seed)]
+			}
+			targetPositions = []NotePosition{}
+			for _, pos := range notePositions {
+				if pos.Pitch[0:1] == targetNoteLetter && lv.includes(pos) {
+					targetPositions = append(targetPositions, pos)
+				}
+			}
+			if levelAccidentals = lv.Accidentals; levelAccidentals {
+				targetAccidental = random.Intn(3) - 1
+				switch targetNoteLetter + []string{"b", "", "#"}[targetAccidental+1] { // keep to the usual black keys
+				case "E#", "B#", "Cb", "Fb":
+					targetAccidental = 0
+				}
+				targetNoteLetter = Pitch{Letter: targetNoteLetter, Accidental: targetAccidental}.name()
+				accidentalChoice.SetSelected("Natural")
+				accidentalBox.Show()
+			}
+			instruction.SetText(fmt.Sprintf("Click all %s notes on the Grand Staff", targetNoteLetter))
+			if daily {
+				instruction.SetText(fmt.Sprintf("Daily Challenge, round %d: click all %s notes on the Grand Staff", random.Round, targetNoteLetter))
+			}
+			if mode == blitz { // no Check: the round moves on as soon as the marks are right
+				checkButton.Disable()
+				blitzRoundStart = blitzClock.Now()
+				instruction.SetText(fmt.Sprintf("Blitz! Click all %s notes; the next round comes as soon as they're right", targetNoteLetter))
+			}
+		}
+		events.publish(RoundStarted{Mode: mode, Round: random.Round, Seed: random.Seed, Target: targetNoteLetter,
+			Targets: targetPositions})
+		feedback.Text = ""
 		staffContainer.Refresh()
 		content.Refresh()
+	}
+
+	// blitzMarked judges each mark as it is made, in blitz mode.
+	blitzMarked := func(p Pitch) {
+		targets := targetPitches()
+		wanted := false
+		for _, target := range targets {
+			wanted = wanted || target == p
+		}
+		if !wanted {
+			score.wrongMark()
+			lastScored = fmt.Sprintf("%s is no %s: -%d", p, targetNoteLetter, blitzWrongPenalty)
+			return
+		}
+		marks := markedPitches()
+		if correct, wrong, missing := scoreRound(targets, marks, false); len(wrong) > 0 || len(missing) > 0 || len(correct) == 0 {
+			return
+		}
+		took := blitzClock.Now().Sub(blitzRoundStart)
+		lastScored = fmt.Sprintf("All %s notes in %.1fs: +%d", targetNoteLetter, took.Seconds(), score.solve(took))
+		noteResult(targets, marks, false, true)
+		newRound()
+	}
+	events.subscribe(func(e gameEvent) {
+		if m, ok := e.(NoteMarked); ok && mode == blitz {
+			blitzMarked(m.Pitch)
+		}
 	})
+	// endBlitz shows the summary and leaves the game in plain find-the-note mode.
+	endBlitz := func() {
+		noteResult(targetPitches(), markedPitches(), false, false) // the round time ran out on
+		timer = nil
+		mode = findTheNote
+		newRound()
+		msg := score.summary()
+		fmt.Println(msg)
+		feedback.Text = msg
+		feedback.Refresh()
+		dialog.ShowInformation("Blitz Over", msg, parentWindow)
+	}
+	startBlitz := func(clk clock) {
+		mode, exercise, score, lastScored, blitzClock = blitz, nil, blitzScore{}, "", clk
+		newRound()
+		var t *countdown
+		t = newCountdown(clk, blitzLength,
+			func(left time.Duration) { // on the countdown's goroutine: it takes gameMu, like the player's clicks do
+				withGame(func() {
+					if timer != t { // a tick from a countdown already stopped
+						return
+					}
+					blitzBar.SetValue(left.Seconds())
+					feedback.Text = fmt.Sprintf("Score %d (%d solved)  %s", score.Points, score.Solved, lastScored)
+					feedback.Refresh()
+				})
+			},
+			func() { // the round on screen is judged and replaced without a click slipping in between
+				withGame(func() {
+					if timer == t {
+						endBlitz()
+					}
+				})
+			})
+		timer = t
+		blitzBar.SetValue(blitzBar.Max)
+		blitzBar.Show()
+		t.Start()
+	}
+
+	// Reset button (aka New Game) — wipes slate clean for a fresh challenge.
+	resetButton := widget.NewButton("New Game", locked(newRound))
 	// No Resize statement for resetButton — HBox in content dictates button size!
 	
 	// Populate content container
 	content.Objects = []fyne.CanvasObject{
+		progressLabel,
 		instruction,
+		blitzBar,
 		staffContainer,
 		container.NewHBox(checkButton, resetButton),
+		letterButtons,
+		chordAnswer.Box,
+		accidentalBox,
 		feedback,
 	}
 
 	// Main layout
 	mainContainer := container.New(layout.NewVBoxLayout(), content)
 
+	// File menu: MIDI export of the current round, or of every round checked so far
+	currentRound := func() []exportRound {
+		return []exportRound{newExportRound(roundNumber, mode, targetNoteLetter, targetPositions, markedNotes)}
+	}
+	loadExercise := func(name string, rounds []exerciseRound) {
+		exercise, exerciseName, exerciseIndex = rounds, name, 0
+		mode = findTheNote
+		newRound()
+	}
+	// ::: MIDI keyboard input: a key press answers a name-the-note round, or marks (or un-marks) its staff position.
+	// answerSung judges a sing/play round against the pitch that was heard (or played on a MIDI keyboard).
+	answerSung := func(heard Pitch) {
+		if mode != singTheNote || len(targetPositions) != 1 {
+			return
+		}
+		target := mustParsePitch(targetPositions[0].Pitch)
+		msg, ok := judgeSung(target, heard)
+		if heard.MIDI() == target.MIDI() {
+			heard = target // recorded as spelled on the staff
+		}
+		noteResult([]Pitch{target}, []Pitch{heard}, false, ok)
+		fmt.Println(msg)
+		feedback.Text = msg
+		feedback.Refresh()
+	}
+
+	playNote = func(p Pitch) {
+		if mode == transposeDrill && drill.ToConcert { // the answer is the concert pitch itself, as played
+			msg := fmt.Sprintf("No, %s isn't it: the blue note sounds %s", p, drill.Concert)
+			if p.MIDI() == drill.Concert.MIDI() {
+				p = drill.Concert // recorded as spelled in the concert key
+				msg = fmt.Sprintf("Correct! Written %s sounds %s on the %s", drill.Written, drill.Concert, currentInstrument.Name)
+			} else if (p.MIDI()-drill.Concert.MIDI())%12 == 0 {
+				msg = fmt.Sprintf("Right note, wrong octave: the blue note sounds %s", drill.Concert)
+			}
+			noteResult([]Pitch{drill.Concert}, []Pitch{p}, false, p == drill.Concert)
+			fmt.Println(msg)
+			feedback.Text = msg
+			feedback.Refresh()
+			return
+		}
+		p = currentInstrument.written(p) // keys sound at concert pitch; the staff shows written pitch
+		if reading != nil {
+			answerReading(p, false)
+			return
+		}
+		if mode == singTheNote {
+			answerSung(p)
+			return
+		}
+		if shownNote != nil {
+			answerLetter(p.Letter)
+			return
+		}
+		if p.Accidental != 0 && mode != buildChord && mode != buildScale && mode != transposeDrill && !levelAccidentals {
+			fmt.Printf("%s has no position on the staff; ignored\n", p)
+			return
+		}
+		for i, mark := range markedNotes {
+			if mark.Pitch == p.String() { // playing a marked note again takes it back, like clicking it
+				removeMark(i)
+				return
+			}
+		}
+		if pos, ok := findNotePosition(notePositions, p.natural().String()); ok {
+			noteX := noteXFor(pos.Pitch)
+			if mode == buildChord {
+				noteX = chordColumnX
+			} else if mode == buildScale {
+				noteX = scaleNoteX(markedNotes, len(scaleTarget.notes()))
+			} else if !staffLayout.free() { // played notes fill the beat slots left to right, after the last marked one
+				slot := 0
+				if n := len(markedNotes); n > 0 {
+					slot = staffLayout.snap(markedNotes[n-1].X) + 1
+				}
+				noteX = staffLayout.slotX(slot % staffLayout.slotCount())
+			}
+			markNote(pos, noteX, p.Accidental)
+		} else {
+			fmt.Printf("%s is off the Grand Staff; ignored\n", p)
+		}
+	}
+	var midiSource io.ReadCloser
+	disconnectMIDI := func() {
+		if midiSource != nil {
+			midiSource.Close()
+			midiSource = nil
+		}
+	}
+	connectMIDI := func(source io.ReadCloser, path string) {
+		disconnectMIDI()
+		midiSource = source
+		go func() {
+			err := readMIDINotes(source, func(note midiNoteEvent) {
+				if note.On { // read on this goroutine; played holding gameMu, so it can't race a click on the staff
+					withGame(func() { playNote(note.Pitch) })
+				}
+			})
+			if err != nil {
+				fmt.Printf("MIDI input %s stopped: %v\n", path, err)
+			}
+		}()
+	}
+
+	// applyLayout swaps in new barlines and time signatures; the marks are cleared since their slots have moved.
+	applyLayout := func(l measureLayout) {
+		rest := staffContainer.Objects[len(lines)+len(layoutObjects):]
+		staffLayout = l
+		layoutObjects = drawMeasures(l, [][2]float32{{100, 340}, {520, 760}}) // the treble and bass staffs, top line to bottom
+		objects := append(append([]fyne.CanvasObject{}, lines...), layoutObjects...)
+		staffContainer.Objects = append(objects, rest...)
+		newRound()
+	}
+
+	// ::: useProfile switches the game over to a student's profile: their history, progress and settings.
+	var currentProfile profile
+	saveSettings := func() {
+		if currentProfile.Dir == "" {
+			return
+		}
+		if err := settingsOf(staffLayout, sightOptions).save(currentProfile); err != nil {
+			fmt.Println("Couldn't save the settings:", err)
+		}
+	}
+	useProfile := func(p profile) {
+		flushRound() // the round on screen was played by the student before
+		currentProfile = p
+		history = openHistory(p.Dir)
+		if err := achievementsEarned.load(p.path(achievementsFileName)); err != nil {
+			fmt.Println("Achievements start over:", err)
+		}
+		progressFile = p.path(progressFileName)
+		var err error
+		if prog, err = loadProgress(progressFile); err != nil {
+			fmt.Println("Progress starts over:", err)
+		}
+		settings, err := loadSettings(p)
+		if err != nil {
+			fmt.Println("Settings are back to the defaults:", err)
+		}
+		layout, sight := settings.apply(staffLayout, sightOptions)
+		sightOptions = sight
+		if err := rememberProfile(p.Name); err != nil {
+			fmt.Println("Couldn't remember the profile:", err)
+		}
+		fmt.Printf("Profile: %s (%s)\n", p.Name, p.Dir)
+		parentWindow.SetTitle(fmt.Sprintf("Rick's Find the Note game: %s", p.Name))
+		progressLabel.SetText(prog.header())
+		applyLayout(layout) // and a new round
+	}
+	if err := migrateLegacyFiles(); err != nil {
+		fmt.Println("Couldn't move the old history into a profile:", err)
+	}
+
+	lowest, highest := mustParsePitch(notePositions[len(notePositions)-1].Pitch), mustParsePitch(notePositions[0].Pitch)
+	// Every menu item runs holding gameMu; the dialogs they open take it again once a choice is made (see clock.go).
+	parentWindow.SetMainMenu(fyne.NewMainMenu(
+		fyne.NewMenu("File",
+			fyne.NewMenuItem("Switch Student Profile...", locked(func() { showProfilePicker(parentWindow, currentProfile.Name, useProfile, flushRound) })),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Import MIDI as Placement Exercise...", locked(func() {
+				showMIDIImportDialog(parentWindow, placeNoteExercise, lowest, highest, loadExercise)
+			})),
+			fyne.NewMenuItem("Import MIDI as Name-the-Note Exercise...", locked(func() {
+				showMIDIImportDialog(parentWindow, nameNoteExercise, lowest, highest, loadExercise)
+			})),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Export Round Targets as MIDI...", locked(func() { showMIDIExportDialog(parentWindow, exportTargets, currentRound) })),
+			fyne.NewMenuItem("Export Round Answers as MIDI...", locked(func() { showMIDIExportDialog(parentWindow, exportAnswers, currentRound) })),
+			fyne.NewMenuItem("Export Session as MIDI...", locked(func() { showMIDIExportDialog(parentWindow, exportBoth, func() []exportRound { return session }) })),
+		),
+		fyne.NewMenu("Modes",
+			fyne.NewMenuItem("Find the Note", locked(func() {
+				mode = findTheNote
+				newRound()
+			})),
+			fyne.NewMenuItem("Sing or Play the Highlighted Note", locked(func() {
+				mode = singTheNote
+				newRound()
+			})),
+			fyne.NewMenuItem("Sight Reading...", locked(func() {
+				showSightReadingDialog(parentWindow, sightOptions, notes, func(o sightReadingOptions) {
+					sightOptions = o
+					saveSettings()
+					mode = sightReadMelody
+					newRound()
+				})
+			})),
+			fyne.NewMenuItem("Build a Triad", locked(func() {
+				mode, chordSevenths = buildChord, false
+				newRound()
+			})),
+			fyne.NewMenuItem("Build a Seventh Chord", locked(func() {
+				mode, chordSevenths = buildChord, true
+				newRound()
+			})),
+			fyne.NewMenuItem("Blitz (60 Seconds)", locked(func() { startBlitz(realClock{}) })),
+			fyne.NewMenuItem("Daily Challenge", locked(func() { // today's seed, from its first round
+				seed := dailySeed(time.Now())
+				random = newGameRandom(seed, rand.NewSource(seed))
+				daily, mode, exercise = true, findTheNote, nil
+				fmt.Printf("Daily Challenge: seed %d\n", seed)
+				newRound()
+			})),
+			fyne.NewMenuItem("Transposition Drill", locked(func() {
+				mode = transposeDrill
+				newRound()
+			})),
+			fyne.NewMenuItem("Build a Scale", locked(func() {
+				mode = buildScale
+				newRound()
+			})),
+			fyne.NewMenuItem("Identify Chords: Triads", locked(func() {
+				mode, chordQuizLevel = identifyChord, 0
+				newRound()
+			})),
+			fyne.NewMenuItem("Identify Chords: Triads and Sevenths", locked(func() {
+				mode, chordQuizLevel = identifyChord, 1
+				newRound()
+			})),
+			fyne.NewMenuItem("Identify Chords: Open Voicings", locked(func() {
+				mode, chordQuizLevel = identifyChord, 2
+				newRound()
+			})),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Rhythm Reading...", locked(func() { showRhythmWindow(RicksFirstGUI, realClock{}, rhythmRand) })),
+			fyne.NewMenuItem("Guitar Fretboard...", locked(func() { showGuitarWindow(RicksFirstGUI, guitarRand) })),
+		),
+		fyne.NewMenu("Settings",
+			fyne.NewMenuItem("Tuning...", locked(func() { showTuningDialog(parentWindow, saveSettings) })),
+			fyne.NewMenuItem("Measures...", locked(func() { showMeasuresDialog(parentWindow, staffLayout, func(l measureLayout) {
+				applyLayout(l)
+				saveSettings()
+			}) })),
+			fyne.NewMenuItem("Instrument...", locked(func() { showInstrumentDialog(parentWindow, func() {
+				saveSettings()
+				newRound()
+			}) })),
+			fyne.NewMenuItem("Target Selection...", locked(func() { showStrategyDialog(parentWindow, func() {
+				saveSettings()
+				newRound()
+			}) })),
+			fyne.NewMenuItem("Level...", locked(func() {
+				showLevelDialog(parentWindow, &prog, func() {
+					saveProgress()
+					progressLabel.SetText(prog.header())
+					newRound()
+				})
+			})),
+		),
+		fyne.NewMenu("Tools",
+			fyne.NewMenuItem("Metronome...", locked(func() { showMetronomeWindow(RicksFirstGUI) })),
+			fyne.NewMenuItem("Statistics...", locked(func() { showStatsWindow(RicksFirstGUI, history) })),
+			fyne.NewMenuItem("Achievements...", locked(func() { showAchievementsDialog(parentWindow, achievementsEarned.State) })),
+		),
+		fyne.NewMenu("Input",
+			fyne.NewMenuItem("Connect MIDI Keyboard...", locked(func() { showMIDIConnectDialog(parentWindow, connectMIDI) })),
+			fyne.NewMenuItem("Disconnect MIDI Keyboard", locked(disconnectMIDI)),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Answer from WAV Recording...", locked(func() {
+				showWAVAnswerDialog(parentWindow, func(heard pitchReading) { answerSung(currentInstrument.written(heard.Pitch)) })
+			})),
+			fyne.NewMenuItem("Save Highlighted Note as WAV...", locked(func() {
+				if mode == singTheNote && len(targetPositions) == 1 {
+					showToneSaveDialog(parentWindow, currentInstrument.concert(mustParsePitch(targetPositions[0].Pitch)))
+				}
+			})),
+		),
+	))
+
+	// Typing a note letter answers like its button does
+	parentWindow.Canvas().SetOnTypedRune(func(r rune) {
+		gameMu.Lock()
+		defer gameMu.Unlock()
+		if letter := strings.ToUpper(string(r)); letterButtons.Visible() && strings.Contains("ABCDEFG", letter) {
+			answerLetter(letter)
+		}
+	})
+
 	// Set up window, and run it
 	parentWindow.SetContent(mainContainer)
+	parentWindow.SetOnClosed(locked(flushRound)) // the round on screen is over too
+	showProfilePicker(parentWindow, lastProfile(), useProfile, flushRound) // the computer is shared: who's practicing?
 	parentWindow.ShowAndRun()
 } // ::: end of main
 
@@ -580,6 +1424,49 @@ Why Interfaces?
 */
 
 
+// noteXFor returns where a note head is centered across the staff: on the ledger lines (center) or on the staff (left).
+func noteXFor(pitch string) float32 {
+	if pitch == "A5" || pitch == "C4" {
+		return 500 // Center of ledger lines (400-600)
+	}
+	return 300 // Halfway between staff left (100) and ledger left (400)
+}
+
+// staffMiddleY returns the middle line of the staff a note belongs to: B4 (Y=220) for the treble, D3 (Y=640) for the bass.
+// Middle C and the D above it count as treble; B3, just above the bass staff, counts as bass.
+func staffMiddleY(y float32) float32 {
+	if y <= 400 {
+		return 220
+	}
+	return 640
+}
+
+// drawStaffNote draws a quarter note at a Grand Staff position, centered on x, with its stem turned the way its staff's
+// middle line says. A5 and middle C get a short ledger line of their own, since with measures they can sit anywhere.
+func drawStaffNote(pos NotePosition, x float32, c color.Color) []fyne.CanvasObject {
+	objects := drawNote(noteGlyph{Value: quarterNote, X: x, Y: pos.Y, StemUp: stemUpFor(pos.Y, staffMiddleY(pos.Y))}, grandStaffMetrics, c)
+	return append(objects, drawLedger(pos, x)...)
+}
+
+// drawLedger draws a short ledger line through a note head at x, for the two Grand Staff notes that sit on one.
+func drawLedger(pos NotePosition, x float32) []fyne.CanvasObject {
+	if pos.Pitch != "A5" && pos.Pitch != "C4" {
+		return nil
+	}
+	w := grandStaffMetrics.HeadW
+	return []fyne.CanvasObject{newStroke(color.Black, x-w, pos.Y, x+w, pos.Y, 2)}
+}
+
+// findNotePosition looks up the staff position of a natural pitch such as "E4".
+func findNotePosition(notePositions []NotePosition, pitch string) (NotePosition, bool) {
+	for _, pos := range notePositions {
+		if pos.Pitch == pitch {
+			return pos, true
+		}
+	}
+	return NotePosition{}, false
+}
+
 // abs returns the absolute value of a float32
 func abs(x float32) float32 {
 	if x < 0 {
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The tempo engine. ::: Every tick is scheduled against the start time (tick n is due at start + n*interval) rather than
+// by sleeping one interval after another, so late wake-ups never add up and the beat cannot drift.
+
+// beatTick is one tick of the metronome.
+type beatTick struct {
+	Index int       // ticks since Start, counting from 0
+	Beat  int       // beat within the bar, counting from 0
+	Sub   int       // subdivision within the beat; 0 is the beat itself
+	Due   time.Time // when the tick was scheduled for (not when it was delivered)
+}
+
+// Downbeat reports whether the tick starts a bar.
+func (t beatTick) Downbeat() bool { return t.Beat == 0 && t.Sub == 0 }
+
+// tempo is the part of a metronome's setup that decides when ticks fall.
+type tempo struct {
+	BPM          float64
+	BeatsPerBar  int
+	Subdivisions int // ticks per beat: 1 for beats only, 2 for eighths, 4 for sixteenths
+}
+
+// interval is the time between ticks.
+func (t tempo) interval() time.Duration {
+	return time.Duration(float64(time.Minute) / (t.BPM * float64(t.Subdivisions)))
+}
+
+// tickAt describes tick n of a run that started at start.
+func (t tempo) tickAt(start time.Time, n int) beatTick {
+	due := start.Add(time.Duration(float64(n) * float64(time.Minute) / (t.BPM * float64(t.Subdivisions))))
+	return beatTick{Index: n, Beat: (n / t.Subdivisions) % t.BeatsPerBar, Sub: n % t.Subdivisions, Due: due}
+}
+
+// metronome emits beatTicks at a tempo, on its own goroutine. Changes to its tempo take effect at the next Start.
+type metronome struct {
+	tempo
+	clock  clock
+	OnTick func(beatTick)
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+// newMetronome returns a stopped metronome; onTick is called from the metronome's goroutine (see withGame).
+func newMetronome(c clock, bpm float64, beatsPerBar, subdivisions int, onTick func(beatTick)) *metronome {
+	if subdivisions < 1 {
+		subdivisions = 1
+	}
+	return &metronome{tempo: tempo{BPM: bpm, BeatsPerBar: beatsPerBar, Subdivisions: subdivisions}, clock: c, OnTick: onTick}
+}
+
+// Start begins ticking and returns the start time; the first tick (a downbeat) is delivered immediately.
+// Starting a running metronome restarts it.
+func (m *metronome) Start() time.Time {
+	m.Stop()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	start := m.clock.Now()
+	m.stop = make(chan struct{})
+	go m.run(m.tempo, start, m.stop)
+	return start
+}
+
+// Stop halts the metronome; it is safe to call when it isn't running.
+func (m *metronome) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
+
+// run delivers ticks until stop is closed. If the goroutine falls behind by more than a tick, the missed ticks are
+// skipped rather than fired in a burst.
+func (m *metronome) run(t tempo, start time.Time, stop chan struct{}) {
+	for n := 0; ; n++ {
+		tick := t.tickAt(start, n)
+		if wait := tick.Due.Sub(m.clock.Now()); wait > 0 {
+			select {
+			case <-stop:
+				return
+			case <-m.clock.After(wait):
+			}
+		}
+		if m.clock.Now().Sub(tick.Due) > t.interval() {
+			continue // hopelessly late for this one, even if it was the sleep that overran
+		}
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if m.OnTick != nil {
+			m.OnTick(tick)
+		}
+	}
+}
+
+// metronomeClick renders the click of one beat: a short high tone, higher and louder on the downbeat.
+func metronomeClick(downbeat bool, sampleRate int) []float64 {
+	freq, volume := 880.0, 0.6
+	if downbeat {
+		freq, volume = 1760, 1
+	}
+	click := synthesizeTone(freq, 0.06, sampleRate)
+	for i := range click {
+		click[i] *= volume
+	}
+	return click
+}
+
+// showMetronomeWindow opens the metronome: a click and a light per beat, the downbeat accented and in red.
+func showMetronomeWindow(a fyne.App) {
+	w := a.NewWindow("Metronome")
+	bpmLabel := widget.NewLabel("")
+	bpm := widget.NewSlider(40, 208)
+	bpm.Step = 1
+	bpm.SetValue(90)
+	beatsPerBar := widget.NewSelect([]string{"2", "3", "4", "5", "6"}, nil)
+	beatsPerBar.SetSelected("4")
+
+	lights := container.NewHBox()
+	off := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	buildLights := func(n int) {
+		lights.Objects = nil
+		for i := 0; i < n; i++ {
+			lights.Add(container.NewGridWrap(fyne.NewSize(30, 30), canvas.NewCircle(off)))
+		}
+		lights.Refresh()
+	}
+	flash := func(tick beatTick) {
+		for i, obj := range lights.Objects {
+			light := obj.(*fyne.Container).Objects[0].(*canvas.Circle)
+			switch {
+			case i != tick.Beat:
+				light.FillColor = off
+			case tick.Downbeat():
+				light.FillColor = color.RGBA{R: 220, G: 0, B: 0, A: 255}
+			default:
+				light.FillColor = color.RGBA{R: 0, G: 160, B: 0, A: 255}
+			}
+			light.Refresh()
+		}
+	}
+
+	player := newSoundPlayer()
+	accent, click := metronomeClick(true, synthSampleRate), metronomeClick(false, synthSampleRate)
+	m := newMetronome(realClock{}, bpm.Value, 4, 1, func(tick beatTick) {
+		sound := click
+		if tick.Downbeat() {
+			sound = accent
+		}
+		if err := player.play(sound, synthSampleRate); err != nil {
+			fmt.Println("Metronome click:", err)
+		}
+		withGame(func() { flash(tick) })
+	})
+	bpm.OnChanged = func(v float64) {
+		bpmLabel.SetText(fmt.Sprintf("%.0f BPM", v))
+	}
+	bpm.OnChanged(bpm.Value)
+	buildLights(4)
+
+	startStop := widget.NewButton("Start", nil)
+	startStop.OnTapped = func() {
+		gameMu.Lock() // the lights are rebuilt here, and flashed by the metronome
+		defer gameMu.Unlock()
+		if startStop.Text == "Stop" {
+			m.Stop()
+			startStop.SetText("Start")
+			return
+		}
+		m.BPM = bpm.Value
+		m.BeatsPerBar, _ = strconv.Atoi(beatsPerBar.Selected) // the choices are all numbers
+		buildLights(m.BeatsPerBar)
+		m.Start()
+		startStop.SetText("Stop")
+	}
+	w.SetOnClosed(m.Stop)
+	w.SetContent(container.NewVBox(bpmLabel, bpm, container.NewHBox(widget.NewLabel("Beats per bar"), beatsPerBar), lights, startStop))
+	w.Resize(fyne.NewSize(360, 220))
+	w.Show()
+}
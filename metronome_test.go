@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// delivered is a tick, and the time on the clock when it arrived.
+type delivered struct {
+	tick beatTick
+	at   time.Time
+}
+
+func startFakeMetronome(bpm float64, beatsPerBar, subdivisions int) (*metronome, *fakeClock, chan delivered, time.Time) {
+	clk := newFakeClock()
+	ticks := make(chan delivered, 1)
+	m := newMetronome(clk, bpm, beatsPerBar, subdivisions, func(tick beatTick) { ticks <- delivered{tick, clk.Now()} })
+	return m, clk, ticks, m.Start()
+}
+
+// Each wake-up is late by the same amount; the ticks stay due on the grid from the start, and are never delivered
+// later than that, however long the metronome runs.
+func TestMetronomeDoesNotDrift(t *testing.T) {
+	tests := []struct {
+		bpm                       float64
+		beatsPerBar, subdivisions int
+		late                      time.Duration
+	}{
+		{60, 4, 1, 0},
+		{90, 4, 1, 3 * time.Millisecond},
+		{120, 3, 2, 7 * time.Millisecond},
+		{133, 4, 4, time.Millisecond},
+		{208, 5, 1, 11 * time.Millisecond},
+	}
+	const ticks = 500
+	for _, tt := range tests {
+		m, clk, delivery, start := startFakeMetronome(tt.bpm, tt.beatsPerBar, tt.subdivisions)
+		interval := float64(time.Minute) / (tt.bpm * float64(tt.subdivisions))
+		for n := 0; n < ticks; n++ {
+			if n > 0 {
+				clk.Advance(<-clk.Sleeps + tt.late)
+			}
+			got := <-delivery
+			due := start.Add(time.Duration(float64(n) * interval))
+			if got.tick.Index != n || got.tick.Due.Sub(due).Abs() > time.Microsecond {
+				t.Fatalf("%g BPM: tick %d was due %v after the start, want tick %d due %v",
+					tt.bpm, got.tick.Index, got.tick.Due.Sub(start), n, due.Sub(start))
+			}
+			if lag := got.at.Sub(got.tick.Due); n > 0 && lag != tt.late {
+				t.Fatalf("%g BPM: tick %d arrived %v after it was due, want %v", tt.bpm, n, lag, tt.late)
+			}
+			if beat, sub := (n/tt.subdivisions)%tt.beatsPerBar, n%tt.subdivisions; got.tick.Beat != beat || got.tick.Sub != sub {
+				t.Fatalf("%g BPM: tick %d is beat %d.%d, want %d.%d", tt.bpm, n, got.tick.Beat, got.tick.Sub, beat, sub)
+			}
+		}
+		m.Stop()
+	}
+}
+
+// A metronome that wakes far too late skips the ticks it missed instead of firing them all at once.
+func TestMetronomeSkipsMissedTicks(t *testing.T) {
+	m, clk, delivery, start := startFakeMetronome(120, 4, 1) // a tick every 500ms
+	defer m.Stop()
+	<-delivery
+	clk.Advance(<-clk.Sleeps + 1250*time.Millisecond) // 1.75s in: ticks 1 and 2 are lost, tick 3 is 250ms late
+	got := <-delivery
+	if got.tick.Index != 3 || got.tick.Due != start.Add(1500*time.Millisecond) {
+		t.Errorf("after the stall got tick %d due %v after the start, want tick 3 due 1.5s after", got.tick.Index, got.tick.Due.Sub(start))
+	}
+	if wait := <-clk.Sleeps; wait != 250*time.Millisecond {
+		t.Errorf("waits %v for tick 4, want 250ms", wait)
+	}
+}
+
+func TestMetronomeStop(t *testing.T) {
+	m, clk, delivery, _ := startFakeMetronome(100, 4, 1)
+	<-delivery
+	<-clk.Sleeps
+	m.Stop()
+	clk.Advance(time.Minute)
+	select {
+	case got := <-delivery:
+		t.Errorf("a stopped metronome delivered tick %d", got.tick.Index)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// The downbeat's click is an octave higher and louder than the others, and both are over well within a fast beat.
+func TestMetronomeClick(t *testing.T) {
+	// crossings counts the upward zero crossings, twice the fundamental's for the same length: an octave up.
+	crossings := func(samples []float64) int {
+		n := 0
+		for i := 1; i < len(samples); i++ {
+			if samples[i-1] < 0 && samples[i] >= 0 {
+				n++
+			}
+		}
+		return n
+	}
+	peak := func(samples []float64) float64 {
+		var p float64
+		for _, s := range samples {
+			p = max(p, s, -s)
+		}
+		return p
+	}
+	accent, click := metronomeClick(true, synthSampleRate), metronomeClick(false, synthSampleRate)
+	if len(accent) != len(click) || len(click) > synthSampleRate/4 { // a quarter second is a beat at 240 BPM
+		t.Fatalf("clicks of %d and %d samples", len(accent), len(click))
+	}
+	if a, c := crossings(accent), crossings(click); a < 2*c-2 || a > 2*c+2 {
+		t.Errorf("the accent crosses zero %d times to the click's %d; want an octave up", a, c)
+	}
+	if peak(accent) <= peak(click) {
+		t.Errorf("the accent peaks at %.2f, no louder than the click's %.2f", peak(accent), peak(click))
+	}
+}
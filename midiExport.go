@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// exportRound is one round of the game as it gets written to a .mid file, so a teacher can review it in a DAW or notation app.
+type exportRound struct {
+	Number  int
+	Mode    gameMode
+	Letter  string  // the target note letter, e.g., "C", or whatever else the mode asked for: "Dm7", "F# major"
+	Targets []Pitch // every staff position the player was asked to find
+	Answers []Pitch // what the player actually marked, in the order it was clicked
+}
+
+// exportKind selects which parts of a round end up in the file.
+type exportKind int
+
+const (
+	exportTargets exportKind = iota // only the notes the player was asked to find
+	exportAnswers                   // only the notes the player marked
+	exportBoth                      // targets and answers on separate tracks
+)
+
+const exportBPM = 90
+
+// newExportRound snapshots a round from the game's own state: its targets, and the marks currently on the staff.
+func newExportRound(number int, mode gameMode, letter string, targets []NotePosition, marks []MarkedNote) exportRound {
+	round := exportRound{Number: number, Mode: mode, Letter: letter}
+	for _, t := range targets {
+		round.Targets = append(round.Targets, mustParsePitch(t.Pitch))
+	}
+	for _, m := range marks {
+		round.Answers = append(round.Answers, mustParsePitch(m.Pitch))
+	}
+	return round
+}
+
+// marker is the text of the round's marker in the conductor track: what the round asked for.
+func (r exportRound) marker() string {
+	var task string
+	switch r.Mode {
+	case singTheNote:
+		task = "sing " + r.Letter
+	case sightReadMelody:
+		task = "read the melody"
+	case buildChord, buildScale:
+		task = "build " + r.Letter
+	case identifyChord:
+		task = "identify " + r.Letter
+	case transposeDrill:
+		task = "transposition drill, written " + r.Letter
+	default: // find the note, the Daily Challenge and blitz
+		task = fmt.Sprintf("find all %s notes", r.Letter)
+	}
+	return fmt.Sprintf("Round %d: %s", r.Number, task)
+}
+
+// buildRoundsSMF lays the rounds out one after another as quarter notes. ::: Track 0 is the conductor (tempo and round markers);
+// targets and answers each get a track of their own so they can be muted or soloed separately.
+func buildRoundsSMF(rounds []exportRound, kind exportKind, bpm int) []smfTrack {
+	conductor := smfTrack{Name: "grokMusic session"}
+	conductor.addTempo(0, bpm)
+	targets := smfTrack{Name: "Targets"}
+	answers := smfTrack{Name: "Answers"}
+
+	const quarter = smfTicksPerQuarter
+	const bar = 4 * quarter
+	var cursor uint32
+	for _, round := range rounds {
+		conductor.addText(cursor, 0x06, round.marker())
+		if kind != exportAnswers {
+			for i, p := range round.Targets {
+				targets.addNote(0, p, cursor+uint32(i)*quarter, quarter, smfDefaultVelocity)
+			}
+		}
+		if kind != exportTargets {
+			for i, p := range round.Answers {
+				answers.addNote(1, p, cursor+uint32(i)*quarter, quarter, smfDefaultVelocity)
+			}
+		}
+		length := len(round.Targets)
+		if len(round.Answers) > length {
+			length = len(round.Answers)
+		}
+		bars := uint32(length)/4 + 1 // always leave at least a beat of silence between rounds
+		cursor += bars * bar
+	}
+
+	tracks := []smfTrack{conductor}
+	if kind != exportAnswers {
+		tracks = append(tracks, targets)
+	}
+	if kind != exportTargets {
+		tracks = append(tracks, answers)
+	}
+	return tracks
+}
+
+// exportRoundsToMIDI writes rounds to w as a Type 0 or Type 1 Standard MIDI File.
+func exportRoundsToMIDI(w io.Writer, rounds []exportRound, kind exportKind, format int) error {
+	if len(rounds) == 0 {
+		return fmt.Errorf("nothing to export yet")
+	}
+	return writeSMF(w, format, buildRoundsSMF(rounds, kind, exportBPM))
+}
+
+// exportFormats are the SMF formats the export offers, in the order of their names.
+var exportFormats = []int{1, 0}
+
+// showMIDIExportDialog asks for the file's format and where to save, then writes whatever rounds() returns at that
+// moment (asked holding gameMu). Type 1 keeps targets and answers on tracks of their own; Type 0, which some older
+// players and phones need, merges everything into one.
+func showMIDIExportDialog(parentWindow fyne.Window, kind exportKind, rounds func() []exportRound) {
+	formats := widget.NewRadioGroup([]string{"Type 1: a track for each part", "Type 0: everything on one track"}, nil)
+	formats.Required = true
+	formats.SetSelected(formats.Options[0])
+	dialog.ShowCustomConfirm("Export MIDI", "Save...", "Cancel", formats, func(ok bool) {
+		if !ok {
+			return
+		}
+		format := exportFormats[0]
+		for i, name := range formats.Options {
+			if name == formats.Selected {
+				format = exportFormats[i]
+			}
+		}
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			if writer == nil { // the player cancelled
+				return
+			}
+			defer writer.Close()
+			var chosen []exportRound
+			withGame(func() { chosen = rounds() })
+			if err := exportRoundsToMIDI(writer, chosen, kind, format); err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			fmt.Printf("Exported MIDI (Type %d) to %s\n", format, writer.URI().Path())
+		}, parentWindow)
+		save.SetFileName("grokMusic.mid")
+		save.Show()
+	}, parentWindow)
+}
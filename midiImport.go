@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// exerciseKind says what the player does with each note of an imported exercise.
+type exerciseKind int
+
+const (
+	placeNoteExercise exerciseKind = iota // click the note's position on the Grand Staff
+	nameNoteExercise                      // the note is drawn for you; pick its letter
+)
+
+// exerciseRound is one note of an exercise built from a MIDI file.
+type exerciseRound struct {
+	Kind  exerciseKind
+	Pitch Pitch
+}
+
+// staffPitch is the pitch as the staff shows it, accidental and all: F#4 sits on F4, with a sharp.
+func (r exerciseRound) staffPitch() string {
+	return r.Pitch.String()
+}
+
+// fitToRange moves a melody into [low, high] by octaves. ::: The whole line is shifted first (by whichever octave shift
+// keeps the most notes in range, preferring the smallest shift) so its shape survives; any stragglers are then folded in one at a time.
+func fitToRange(pitches []Pitch, low, high Pitch) []Pitch {
+	lo, hi := low.MIDI(), high.MIDI()
+	bestShift, bestCount := 0, -1
+	for _, shift := range []int{0, -12, 12, -24, 24, -36, 36} {
+		count := 0
+		for _, p := range pitches {
+			if m := p.MIDI() + shift; m >= lo && m <= hi {
+				count++
+			}
+		}
+		if count > bestCount {
+			bestShift, bestCount = shift, count
+		}
+	}
+
+	var fitted []Pitch
+	for _, p := range pitches {
+		m := p.MIDI() + bestShift
+		for m < lo {
+			m += 12
+		}
+		for m > hi {
+			m -= 12
+		}
+		if m < lo { // the range is narrower than an octave and this note can't land in it
+			continue
+		}
+		fitted = append(fitted, pitchFromMIDI(m))
+	}
+	return fitted
+}
+
+// exerciseFromMIDI turns a parsed file's melody into rounds the game can play, transposed by octaves into [low, high].
+func exerciseFromMIDI(f *smfFile, kind exerciseKind, low, high Pitch) []exerciseRound {
+	var rounds []exerciseRound
+	for _, p := range fitToRange(f.melody(), low, high) {
+		rounds = append(rounds, exerciseRound{Kind: kind, Pitch: p})
+	}
+	return rounds
+}
+
+// showMIDIImportDialog lets a teacher pick a .mid file and hands the resulting exercise to onLoaded,
+// which runs holding gameMu.
+func showMIDIImportDialog(parentWindow fyne.Window, kind exerciseKind, low, high Pitch, onLoaded func(name string, rounds []exerciseRound)) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if reader == nil { // cancelled
+			return
+		}
+		defer reader.Close()
+		f, err := readSMF(reader)
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		rounds := exerciseFromMIDI(f, kind, low, high)
+		if len(rounds) == 0 {
+			dialog.ShowError(fmt.Errorf("no playable notes found in %s", reader.URI().Name()), parentWindow)
+			return
+		}
+		name := filepath.Base(reader.URI().Path())
+		fmt.Printf("Imported %d notes from %s\n", len(rounds), name)
+		withGame(func() { onLoaded(name, rounds) })
+	}, parentWindow)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".mid", ".midi"}))
+	open.Show()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// MIDI keyboard input. ::: Anything that yields raw MIDI bytes is an input: a /dev/snd/midiC*D* device node, a named pipe,
+// or a replay file (a raw byte capture, or a .mid file flattened back into bytes) for practicing and testing without hardware.
+
+// midiNoteEvent is a decoded key press or release.
+type midiNoteEvent struct {
+	Channel  int
+	Pitch    Pitch
+	Velocity int
+	On       bool // false for a note-off (or a note-on with velocity 0)
+}
+
+// midiDecoder turns a live MIDI byte stream into complete channel messages. It copes with running status,
+// real-time bytes (clock, active sensing) arriving mid-message, and sysex dumps, which it skips.
+type midiDecoder struct {
+	running byte   // the current channel status byte
+	data    []byte // data bytes collected so far for the current message
+	inSysex bool
+}
+
+// feed consumes one byte and returns a complete channel message (status first) when one has just finished.
+func (d *midiDecoder) feed(b byte) ([]byte, bool) {
+	switch {
+	case b >= 0xF8: // real-time: may appear anywhere, never disturbs running status
+		return nil, false
+	case b == 0xF0: // sysex also cancels running status
+		d.running, d.data, d.inSysex = 0, nil, true
+		return nil, false
+	case b == 0xF7:
+		d.inSysex = false
+		return nil, false
+	case b >= 0xF1: // system common messages cancel running status; we have no use for them
+		d.running, d.data, d.inSysex = 0, nil, false
+		return nil, false
+	case b >= 0x80:
+		d.running, d.data, d.inSysex = b, d.data[:0], false
+		return nil, false
+	}
+
+	if d.inSysex || d.running == 0 { // a data byte with nothing to attach it to
+		return nil, false
+	}
+	d.data = append(d.data, b)
+	if len(d.data) < channelEventDataSize(d.running) {
+		return nil, false
+	}
+	msg := append([]byte{d.running}, d.data...)
+	d.data = d.data[:0] // keep the status byte: the next data bytes may use running status
+	return msg, true
+}
+
+// decodeMIDINote interprets a channel message as a note event, if it is one.
+func decodeMIDINote(msg []byte) (midiNoteEvent, bool) {
+	if len(msg) != 3 {
+		return midiNoteEvent{}, false
+	}
+	status := msg[0] & 0xF0
+	if status != 0x80 && status != 0x90 {
+		return midiNoteEvent{}, false
+	}
+	return midiNoteEvent{
+		Channel:  int(msg[0] & 0x0F),
+		Pitch:    pitchFromMIDI(int(msg[1])),
+		Velocity: int(msg[2]),
+		On:       status == 0x90 && msg[2] > 0,
+	}, true
+}
+
+// readMIDINotes decodes r until it runs dry, calling onNote for every note event. It returns nil at EOF.
+func readMIDINotes(r io.Reader, onNote func(midiNoteEvent)) error {
+	br := bufio.NewReader(r)
+	var decoder midiDecoder
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg, ok := decoder.feed(b); ok {
+			if note, ok := decodeMIDINote(msg); ok {
+				onNote(note)
+			}
+		}
+	}
+}
+
+// smfReplayBytes flattens a parsed .mid file back into the raw byte stream a keyboard would have sent (timing is dropped).
+func smfReplayBytes(f *smfFile) []byte {
+	var all smfTrack
+	for _, t := range f.Tracks {
+		all.Events = append(all.Events, t.Events...)
+	}
+	sortSMFEvents(all.Events)
+	var out []byte
+	for _, e := range all.Events {
+		if e.Data[0] != 0xFF {
+			out = append(out, e.Data...)
+		}
+	}
+	return out
+}
+
+// openMIDISource opens a MIDI input by path: .mid/.midi files are replayed as a byte stream; anything else
+// (a device node, a named pipe, a raw capture) is read as-is.
+func openMIDISource(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".mid" && ext != ".midi" {
+		return f, nil
+	}
+	defer f.Close()
+	smf, err := readSMF(f)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(smfReplayBytes(smf))), nil
+}
+
+// midiDevicePaths lists the raw MIDI device nodes ALSA exposes on this machine.
+func midiDevicePaths() []string {
+	paths, _ := filepath.Glob("/dev/snd/midiC*D*")
+	return paths
+}
+
+// showMIDIConnectDialog asks for a device or replay path and starts reading it in the background.
+// onConnect, run holding gameMu, receives the opened source so the caller can close it to disconnect.
+func showMIDIConnectDialog(parentWindow fyne.Window, onConnect func(source io.ReadCloser, path string)) {
+	path := widget.NewSelectEntry(midiDevicePaths())
+	path.SetPlaceHolder("/dev/snd/midiC1D0, a named pipe, or a .mid/.raw replay file")
+	if devices := midiDevicePaths(); len(devices) > 0 {
+		path.SetText(devices[0])
+	}
+	dialog.ShowForm("Connect MIDI Input", "Connect", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Source", path)},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			source, err := openMIDISource(path.Text)
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			fmt.Printf("MIDI input connected: %s\n", path.Text)
+			withGame(func() { onConnect(source, path.Text) })
+		}, parentWindow)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMIDIDecoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream []byte
+		want   [][]byte
+	}{
+		{"note on and off", []byte{0x90, 0x3C, 0x40, 0x80, 0x3C, 0x00},
+			[][]byte{{0x90, 0x3C, 0x40}, {0x80, 0x3C, 0x00}}},
+		{"running status", []byte{0x91, 0x3C, 0x40, 0x3E, 0x40, 0x3C, 0x00},
+			[][]byte{{0x91, 0x3C, 0x40}, {0x91, 0x3E, 0x40}, {0x91, 0x3C, 0x00}}},
+		{"real-time bytes mid-message", []byte{0x90, 0xF8, 0x3C, 0xFE, 0x40, 0xF8, 0x3E, 0x40},
+			[][]byte{{0x90, 0x3C, 0x40}, {0x90, 0x3E, 0x40}}},
+		{"one data byte", []byte{0xC0, 0x05, 0x06, 0xD0, 0x30},
+			[][]byte{{0xC0, 0x05}, {0xC0, 0x06}, {0xD0, 0x30}}},
+		{"sysex skipped, and it cancels running status", []byte{0x90, 0x3C, 0x40, 0xF0, 0x7E, 0x7F, 0xF7, 0x3E, 0x40, 0x90, 0x3E, 0x40},
+			[][]byte{{0x90, 0x3C, 0x40}, {0x90, 0x3E, 0x40}}},
+		{"system common cancels running status", []byte{0x90, 0x3C, 0x40, 0xF3, 0x01, 0x3E, 0x40},
+			[][]byte{{0x90, 0x3C, 0x40}}},
+		{"data before any status", []byte{0x3C, 0x40, 0x90, 0x3C, 0x40},
+			[][]byte{{0x90, 0x3C, 0x40}}},
+		{"a new status drops a half-read message", []byte{0x90, 0x3C, 0x80, 0x3C, 0x00},
+			[][]byte{{0x80, 0x3C, 0x00}}},
+	}
+	for _, tt := range tests {
+		var d midiDecoder
+		var got [][]byte
+		for _, b := range tt.stream {
+			if msg, ok := d.feed(b); ok {
+				got = append(got, msg)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got % X, want % X", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeMIDINote(t *testing.T) {
+	tests := []struct {
+		msg  []byte
+		want midiNoteEvent
+		ok   bool
+	}{
+		{[]byte{0x90, 60, 100}, midiNoteEvent{Channel: 0, Pitch: mustParsePitch("C4"), Velocity: 100, On: true}, true},
+		{[]byte{0x93, 61, 1}, midiNoteEvent{Channel: 3, Pitch: mustParsePitch("C#4"), Velocity: 1, On: true}, true},
+		{[]byte{0x90, 60, 0}, midiNoteEvent{Channel: 0, Pitch: mustParsePitch("C4"), Velocity: 0, On: false}, true},
+		{[]byte{0x8F, 21, 64}, midiNoteEvent{Channel: 15, Pitch: mustParsePitch("A0"), Velocity: 64, On: false}, true},
+		{[]byte{0xB0, 64, 127}, midiNoteEvent{}, false}, // sustain pedal
+		{[]byte{0xC0, 5}, midiNoteEvent{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := decodeMIDINote(tt.msg)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("decodeMIDINote(% X) = %+v, %v; want %+v, %v", tt.msg, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// A .mid file replayed as a keyboard's byte stream gives back its notes, in order.
+func TestReadMIDINotesReplaysSMF(t *testing.T) {
+	track := smfTrack{Name: "Scale"}
+	names := []string{"C4", "D4", "E4", "F#4"}
+	for i, name := range names {
+		track.addNote(0, mustParsePitch(name), uint32(i)*smfTicksPerQuarter, smfTicksPerQuarter, smfDefaultVelocity)
+	}
+	var buf bytes.Buffer
+	if err := writeSMF(&buf, 0, []smfTrack{track}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := readSMF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var played []string
+	err = readMIDINotes(bytes.NewReader(smfReplayBytes(f)), func(note midiNoteEvent) {
+		if note.On {
+			played = append(played, note.Pitch.String())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(played, names) {
+		t.Errorf("played %v, want %v", played, names)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+)
+
+// The note renderer: oval heads (filled or hollow), stems, flags, beams, augmentation dots and rests, built from plain
+// canvas objects. ::: Every mode that shows notation draws it through here, so a quarter note looks the same everywhere.
+
+// noteMetrics sizes the notation for a staff; Gap is the distance between two staff lines.
+type noteMetrics struct {
+	Gap   float32
+	HeadW float32
+	HeadH float32
+	Stem  float32 // stem length, measured from the center of the head
+}
+
+// metricsForGap scales the notation to a staff whose lines are gap pixels apart; a head fills most of a space.
+func metricsForGap(gap float32) noteMetrics {
+	return noteMetrics{Gap: gap, HeadW: gap * 1.25, HeadH: gap * 0.9, Stem: gap * 3.5}
+}
+
+// grandStaffMetrics are deliberately compact for the 60px line spacing of the Grand Staff, so notes stay readable
+// next to one another without filling the whole space.
+var grandStaffMetrics = noteMetrics{Gap: 60, HeadW: 30, HeadH: 22, Stem: 90}
+
+// noteGlyph is one note or rest to be drawn.
+type noteGlyph struct {
+	Value  noteValue
+	Dotted bool
+	Rest   bool
+	X, Y   float32 // center of the head (for a rest: its center line)
+	StemUp bool
+	OnLine bool // the head sits on a line, so its dot moves up into the space
+}
+
+// stemUpFor applies the usual rule: notes below the middle line of their staff get stems up, the rest stems down.
+func stemUpFor(y, middleY float32) bool {
+	return y > middleY
+}
+
+// flagCount is how many flags (or beams) a value carries.
+func flagCount(v noteValue) int {
+	switch v {
+	case eighthNote:
+		return 1
+	case sixteenthNote:
+		return 2
+	}
+	return 0
+}
+
+// stemX is the x-coordinate of a glyph's stem: the right side of the head going up, the left side going down.
+func (g noteGlyph) stemX(m noteMetrics) float32 {
+	if g.StemUp {
+		return g.X + m.HeadW/2 - 1
+	}
+	return g.X - m.HeadW/2 + 1
+}
+
+// drawNoteHead draws just the head; whole and half notes are hollow. It is also what hit-testing uses.
+func drawNoteHead(g noteGlyph, m noteMetrics, c color.Color) *canvas.Circle {
+	head := canvas.NewCircle(c)
+	if g.Value <= halfNote {
+		head.FillColor = color.Transparent
+		head.StrokeColor = c
+		head.StrokeWidth = float32(math.Max(2, float64(m.HeadH/8)))
+	}
+	head.Resize(fyne.NewSize(m.HeadW, m.HeadH))
+	head.Move(fyne.NewPos(g.X-m.HeadW/2, g.Y-m.HeadH/2))
+	return head
+}
+
+// drawNote draws a single, unbeamed note or rest: head, stem, flags and dot.
+func drawNote(g noteGlyph, m noteMetrics, c color.Color) []fyne.CanvasObject {
+	if g.Rest {
+		return drawRest(g, m, c)
+	}
+	objects := []fyne.CanvasObject{drawNoteHead(g, m, c)}
+	if g.Value != wholeNote {
+		tip := g.Y - m.Stem
+		if !g.StemUp {
+			tip = g.Y + m.Stem
+		}
+		objects = append(objects, newStroke(c, g.stemX(m), g.Y, g.stemX(m), tip, m.HeadH/8))
+		for f := 0; f < flagCount(g.Value); f++ { // a flag is a short sweep away from the stem tip, toward the head
+			dir := float32(1)
+			if !g.StemUp {
+				dir = -1
+			}
+			y := tip + dir*float32(f)*m.HeadH*0.6
+			objects = append(objects,
+				newStroke(c, g.stemX(m), y, g.stemX(m)+m.HeadW*0.5, y+dir*m.HeadH*0.7, m.HeadH/7),
+				newStroke(c, g.stemX(m)+m.HeadW*0.5, y+dir*m.HeadH*0.7, g.stemX(m)+m.HeadW*0.45, y+dir*m.HeadH*1.4, m.HeadH/9),
+			)
+		}
+	}
+	return append(objects, drawDot(g, m, c)...)
+}
+
+// drawDot draws the augmentation dot, if the glyph has one.
+func drawDot(g noteGlyph, m noteMetrics, c color.Color) []fyne.CanvasObject {
+	if !g.Dotted {
+		return nil
+	}
+	size := m.HeadH / 3
+	y := g.Y
+	if g.OnLine {
+		y -= m.Gap / 4 // a dot never sits on a line
+	}
+	dot := canvas.NewCircle(c)
+	dot.Resize(fyne.NewSize(size, size))
+	dot.Move(fyne.NewPos(g.X+m.HeadW/2+size, y-size/2))
+	return []fyne.CanvasObject{dot}
+}
+
+// drawAccidental draws a sharp or flat sign just left of a note head centered on (x, y).
+func drawAccidental(accidental int, x, y float32, m noteMetrics, c color.Color) fyne.CanvasObject {
+	sign := "#"
+	if accidental < 0 {
+		sign = "b"
+	}
+	text := canvas.NewText(sign, c)
+	text.TextSize = m.HeadH * 1.6
+	text.TextStyle = fyne.TextStyle{Bold: true}
+	size := text.MinSize()
+	text.Resize(size)
+	text.Move(fyne.NewPos(x-m.HeadW-size.Width, y-size.Height/2))
+	return text
+}
+
+// drawRest draws a rest centered on g.Y: whole rests hang from a line, half rests sit on one, shorter rests are upright
+// strokes with a hook per flag.
+func drawRest(g noteGlyph, m noteMetrics, c color.Color) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	block := canvas.NewRectangle(c)
+	switch g.Value {
+	case wholeNote:
+		block.Resize(fyne.NewSize(m.HeadW, m.HeadH/2))
+		block.Move(fyne.NewPos(g.X-m.HeadW/2, g.Y))
+		objects = append(objects, block)
+	case halfNote:
+		block.Resize(fyne.NewSize(m.HeadW, m.HeadH/2))
+		block.Move(fyne.NewPos(g.X-m.HeadW/2, g.Y-m.HeadH/2))
+		objects = append(objects, block)
+	case quarterNote: // a zig-zag
+		h := m.HeadH * 2.4
+		top := g.Y - h/2
+		w := m.HeadW / 3
+		points := []fyne.Position{
+			fyne.NewPos(g.X-w, top), fyne.NewPos(g.X+w, top+h*0.3), fyne.NewPos(g.X-w, top+h*0.55),
+			fyne.NewPos(g.X+w, top+h*0.8), fyne.NewPos(g.X-w/2, top+h),
+		}
+		for i := 0; i+1 < len(points); i++ {
+			objects = append(objects, newStroke(c, points[i].X, points[i].Y, points[i+1].X, points[i+1].Y, m.HeadH/6))
+		}
+	default: // eighth and sixteenth rests: a slanted stroke with a blob per flag
+		h := m.HeadH * 1.2 * float32(flagCount(g.Value))
+		top := g.Y - h/2
+		objects = append(objects, newStroke(c, g.X+m.HeadW/4, top, g.X-m.HeadW/6, top+h, m.HeadH/8))
+		for f := 0; f < flagCount(g.Value); f++ {
+			blob := canvas.NewCircle(c)
+			size := m.HeadH / 2.5
+			blob.Resize(fyne.NewSize(size, size))
+			blob.Move(fyne.NewPos(g.X-m.HeadW/3, top+float32(f)*m.HeadH*1.1))
+			objects = append(objects, blob)
+		}
+	}
+	return append(objects, drawDot(g, m, c)...)
+}
+
+// drawBeamedGroup draws eighths and sixteenths that share a beat with beams instead of flags. The whole group takes
+// the first glyph's stem direction and a flat primary beam; sixteenths get a second beam, or a stub when their
+// neighbour is an eighth.
+func drawBeamedGroup(group []noteGlyph, m noteMetrics, c color.Color) []fyne.CanvasObject {
+	if len(group) == 1 {
+		return drawNote(group[0], m, c)
+	}
+	up := group[0].StemUp
+	group = append([]noteGlyph(nil), group...) // stems are redirected below; leave the caller's glyphs alone
+	for i := range group {
+		group[i].StemUp = up
+	}
+	beamY := group[0].Y
+	for _, g := range group {
+		if up {
+			beamY = float32(math.Min(float64(beamY), float64(g.Y)))
+		} else {
+			beamY = float32(math.Max(float64(beamY), float64(g.Y)))
+		}
+	}
+	dir := float32(-1) // which way stems grow
+	if !up {
+		dir = 1
+	}
+	beamY += dir * m.Stem
+	thick := m.HeadH / 3.5
+
+	var objects []fyne.CanvasObject
+	for _, g := range group {
+		objects = append(objects, drawNoteHead(g, m, c), newStroke(c, g.stemX(m), g.Y, g.stemX(m), beamY, m.HeadH/8))
+		objects = append(objects, drawDot(g, m, c)...)
+	}
+	first, last := group[0].stemX(m), group[len(group)-1].stemX(m)
+	objects = append(objects, newStroke(c, first, beamY, last, beamY, thick))
+
+	second := beamY - dir*thick*1.6
+	for i, g := range group {
+		if g.Value != sixteenthNote {
+			continue
+		}
+		switch {
+		case i+1 < len(group) && group[i+1].Value == sixteenthNote:
+			objects = append(objects, newStroke(c, g.stemX(m), second, group[i+1].stemX(m), second, thick))
+		case i > 0 && group[i-1].Value == sixteenthNote:
+			// already joined from the left
+		case i+1 < len(group):
+			objects = append(objects, newStroke(c, g.stemX(m), second, g.stemX(m)+m.HeadW*0.6, second, thick))
+		default:
+			objects = append(objects, newStroke(c, g.stemX(m)-m.HeadW*0.6, second, g.stemX(m), second, thick))
+		}
+	}
+	return objects
+}
+
+// beamGroups splits a measure into runs of eighths and sixteenths that fall within the same beat; any other event
+// is a group of its own. It returns the indexes of each group's events.
+func beamGroups(measure rhythmMeasure) [][]int {
+	starts := measure.starts()
+	var groups [][]int
+	for i, e := range measure {
+		beamable := !e.Rest && flagCount(e.Value) > 0
+		if n := len(groups); beamable && n > 0 {
+			prev := groups[n-1][len(groups[n-1])-1]
+			pe := measure[prev]
+			if !pe.Rest && flagCount(pe.Value) > 0 && math.Floor(starts[prev]) == math.Floor(starts[i]) {
+				groups[n-1] = append(groups[n-1], i)
+				continue
+			}
+		}
+		groups = append(groups, []int{i})
+	}
+	return groups
+}
+
+// newStroke is a straight line segment.
+func newStroke(c color.Color, x1, y1, x2, y2, width float32) *canvas.Line {
+	line := canvas.NewLine(c)
+	line.Position1, line.Position2 = fyne.NewPos(x1, y1), fyne.NewPos(x2, y2)
+	line.StrokeWidth = width
+	return line
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+
+	"fyne.io/fyne/v2/canvas"
+)
+
+// A note is a head, then a stem unless it is whole, two strokes per flag, and its dot.
+func TestDrawNoteParts(t *testing.T) {
+	m := metricsForGap(12)
+	tests := []struct {
+		g      noteGlyph
+		parts  int
+		hollow bool
+	}{
+		{noteGlyph{Value: wholeNote}, 1, true},
+		{noteGlyph{Value: halfNote, StemUp: true}, 2, true},
+		{noteGlyph{Value: quarterNote, StemUp: true}, 2, false},
+		{noteGlyph{Value: eighthNote}, 4, false},
+		{noteGlyph{Value: sixteenthNote, StemUp: true}, 6, false},
+		{noteGlyph{Value: quarterNote, Dotted: true}, 3, false},
+	}
+	for _, tt := range tests {
+		objects := drawNote(tt.g, m, color.Black)
+		head := objects[0].(*canvas.Circle)
+		if len(objects) != tt.parts || (head.FillColor == color.Transparent) != tt.hollow {
+			t.Errorf("%+v: %d parts, hollow %v; want %d, %v", tt.g, len(objects), head.FillColor == color.Transparent, tt.parts, tt.hollow)
+		}
+	}
+}
+
+// Stems go up on the right of the head below the middle line, and down on the left above it.
+func TestStems(t *testing.T) {
+	m := metricsForGap(20)
+	for _, tt := range []struct {
+		y      float32
+		up     bool
+		stemDX float32
+	}{
+		{300, true, m.HeadW/2 - 1},
+		{100, false, -m.HeadW/2 + 1},
+	} {
+		g := noteGlyph{Value: quarterNote, X: 50, Y: tt.y, StemUp: stemUpFor(tt.y, 200)}
+		if g.StemUp != tt.up || g.stemX(m) != g.X+tt.stemDX {
+			t.Errorf("y %g: stem up %v at x %g", tt.y, g.StemUp, g.stemX(m))
+		}
+		stem := drawNote(g, m, color.Black)[1].(*canvas.Line)
+		if tip := stem.Position2.Y; tt.up && tip >= tt.y || !tt.up && tip <= tt.y {
+			t.Errorf("y %g: the stem ends at %g", tt.y, tip)
+		}
+	}
+}
+
+func TestBeamGroups(t *testing.T) {
+	q, e, s := rhythmEvent{Value: quarterNote}, rhythmEvent{Value: eighthNote}, rhythmEvent{Value: sixteenthNote}
+	eRest := rhythmEvent{Value: eighthNote, Rest: true}
+	dottedE := rhythmEvent{Value: eighthNote, Dotted: true}
+	tests := []struct {
+		name    string
+		measure rhythmMeasure
+		want    [][]int
+	}{
+		{"quarters", rhythmMeasure{q, q, q, q}, [][]int{{0}, {1}, {2}, {3}}},
+		{"eighths by the beat", rhythmMeasure{e, e, e, e, q, q}, [][]int{{0, 1}, {2, 3}, {4}, {5}}},
+		{"sixteenths by the beat", rhythmMeasure{s, s, s, s, e, s, s, q, q}, [][]int{{0, 1, 2, 3}, {4, 5, 6}, {7}, {8}}},
+		{"a rest breaks the beam", rhythmMeasure{eRest, e, e, e, q, q}, [][]int{{0}, {1}, {2, 3}, {4}, {5}}},
+		{"dotted eighth and sixteenth", rhythmMeasure{dottedE, s, q, q, q}, [][]int{{0, 1}, {2}, {3}, {4}}},
+		{"no beam across a beat", rhythmMeasure{q, dottedE, s, e, e, q}, [][]int{{0}, {1, 2}, {3, 4}, {5}}},
+	}
+	for _, tt := range tests {
+		if got := beamGroups(tt.measure); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// A beamed group's stems all take the first note's direction and end on one flat beam, without changing the glyphs
+// handed in.
+func TestDrawBeamedGroup(t *testing.T) {
+	m := metricsForGap(12)
+	group := []noteGlyph{
+		{Value: eighthNote, X: 100, Y: 90, StemUp: true},
+		{Value: sixteenthNote, X: 130, Y: 70, StemUp: false},
+		{Value: sixteenthNote, X: 160, Y: 80, StemUp: false},
+	}
+	before := append([]noteGlyph(nil), group...)
+	objects := drawBeamedGroup(group, m, color.Black)
+	if !reflect.DeepEqual(group, before) {
+		t.Errorf("the glyphs were changed to %+v", group)
+	}
+	beamY := float32(70) - m.Stem // above the highest head
+	for i := range group {
+		stem := objects[2*i+1].(*canvas.Line)
+		if stem.Position2.Y != beamY {
+			t.Errorf("stem %d ends at %g, not on the beam at %g", i+1, stem.Position2.Y, beamY)
+		}
+	}
+	// heads and stems, the primary beam, and the second beam joining the two sixteenths
+	if len(objects) != 2*len(group)+2 {
+		t.Errorf("%d objects, want %d", len(objects), 2*len(group)+2)
+	}
+}
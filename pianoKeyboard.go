@@ -0,0 +1,114 @@
+package main
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+// The on-screen piano keyboard. ::: It spans the Grand Staff's range, lights up a key for every note on the staff, and
+// a click on a key plays that note into the game, just as a MIDI keyboard would.
+
+const (
+	pianoWhiteWidth  = 36
+	pianoWhiteHeight = 120
+	pianoBlackWidth  = 22
+	pianoBlackHeight = 75
+)
+
+var (
+	pianoWhite     = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	pianoBlack     = color.RGBA{R: 20, G: 20, B: 20, A: 255}
+	pianoLitWhite  = color.RGBA{R: 255, G: 120, B: 120, A: 255}
+	pianoLitBlack  = color.RGBA{R: 190, G: 30, B: 30, A: 255}
+	pianoKeyBorder = color.RGBA{R: 80, G: 80, B: 80, A: 255}
+)
+
+// pianoKey is one drawn key.
+type pianoKey struct {
+	Pitch Pitch
+	Black bool
+	Rect  *canvas.Rectangle
+}
+
+// pianoKeyboard is a row of keys from one natural to another; Object holds them all, sized to fit, for the caller to
+// move into place.
+type pianoKeyboard struct {
+	Object fyne.CanvasObject
+	OnKey  func(Pitch)
+	keys   []*pianoKey // the white keys first, then the black ones on top of them
+}
+
+// newPianoKeyboard lays out the keys from low to high, both naturals; sharps name the black keys.
+func newPianoKeyboard(low, high Pitch, onKey func(Pitch)) *pianoKeyboard {
+	k := &pianoKeyboard{OnKey: onKey}
+	var whites, blacks []fyne.CanvasObject
+	for i, step := 0, low.step(); step <= high.step(); i, step = i+1, step+1 {
+		p := naturalAt(step)
+		white := &pianoKey{Pitch: p, Rect: canvas.NewRectangle(pianoWhite)}
+		white.Rect.StrokeColor, white.Rect.StrokeWidth = pianoKeyBorder, 1
+		white.Rect.Resize(fyne.NewSize(pianoWhiteWidth, pianoWhiteHeight))
+		white.Rect.Move(fyne.NewPos(float32(i*pianoWhiteWidth), 0))
+		k.keys = append(k.keys, white)
+		whites = append(whites, white.Rect)
+		if p.Letter == "E" || p.Letter == "B" || step == high.step() {
+			continue
+		}
+		sharp := p
+		sharp.Accidental = 1
+		black := &pianoKey{Pitch: sharp, Black: true, Rect: canvas.NewRectangle(pianoBlack)}
+		black.Rect.Resize(fyne.NewSize(pianoBlackWidth, pianoBlackHeight))
+		black.Rect.Move(fyne.NewPos(float32((i+1)*pianoWhiteWidth)-pianoBlackWidth/2, 0))
+		k.keys = append(k.keys, black)
+		blacks = append(blacks, black.Rect)
+	}
+	size := fyne.NewSize(float32(len(whites)*pianoWhiteWidth), pianoWhiteHeight)
+	tapArea := canvas.NewRectangle(color.Transparent)
+	tapArea.Resize(size)
+	tappable := &TappableCanvas{CanvasObject: tapArea, OnTapped: func(e *fyne.PointEvent) {
+		if key := k.keyAt(e.Position); key != nil && k.OnKey != nil {
+			k.OnKey(key.Pitch)
+		}
+	}}
+	k.Object = container.NewWithoutLayout(append(append(whites, blacks...), tappable)...)
+	k.Object.Resize(size)
+	return k
+}
+
+// keyAt finds the key under a point; black keys sit on top, so they are tried first.
+func (k *pianoKeyboard) keyAt(at fyne.Position) *pianoKey {
+	for _, black := range []bool{true, false} {
+		for _, key := range k.keys {
+			pos, size := key.Rect.Position(), key.Rect.Size()
+			if key.Black == black && at.X >= pos.X && at.X < pos.X+size.Width && at.Y >= pos.Y && at.Y < pos.Y+size.Height {
+				return key
+			}
+		}
+	}
+	return nil
+}
+
+// highlight lights exactly the keys that sound the given pitches; enharmonic spellings light the same key.
+func (k *pianoKeyboard) highlight(pitches []Pitch) {
+	lit := map[int]bool{}
+	for _, p := range pitches {
+		lit[p.MIDI()] = true
+	}
+	for _, key := range k.keys {
+		fill := pianoWhite
+		switch {
+		case key.Black && lit[key.Pitch.MIDI()]:
+			fill = pianoLitBlack
+		case key.Black:
+			fill = pianoBlack
+		case lit[key.Pitch.MIDI()]:
+			fill = pianoLitWhite
+		}
+		if key.Rect.FillColor != fill {
+			key.Rect.FillColor = fill
+			key.Rect.Refresh()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pitch is the note model behind notePositions: a letter, an accidental, and an octave in scientific pitch notation.
+// ::: "C4" is middle C (MIDI 60); "F#3" is Letter "F", Accidental +1, Octave 3.
+type Pitch struct {
+	Letter     string // "C" through "B"
+	Accidental int    // -1 per flat, +1 per sharp, 0 for a natural
+	Octave     int    // octave number; it changes between B and C, as in B3 -> C4
+}
+
+// letterSemitones maps each natural note letter to its semitone offset above C.
+var letterSemitones = map[string]int{"C": 0, "D": 2, "E": 4, "F": 5, "G": 7, "A": 9, "B": 11}
+
+// noteLetters lists the seven natural letters in staff order, starting from C.
+var noteLetters = []string{"C", "D", "E", "F", "G", "A", "B"}
+
+// parsePitch turns a note name such as "A5", "Bb3" or "F#4" into a Pitch.
+func parsePitch(name string) (Pitch, error) {
+	name = strings.TrimSpace(name)
+	if len(name) < 2 {
+		return Pitch{}, fmt.Errorf("pitch %q is too short", name)
+	}
+	p := Pitch{Letter: strings.ToUpper(name[0:1])}
+	if _, ok := letterSemitones[p.Letter]; !ok {
+		return Pitch{}, fmt.Errorf("pitch %q has no note letter", name)
+	}
+	rest := name[1:]
+	for len(rest) > 0 && (rest[0] == '#' || rest[0] == 'b') { // sharps and flats sit between the letter and the octave
+		if rest[0] == '#' {
+			p.Accidental++
+		} else {
+			p.Accidental--
+		}
+		rest = rest[1:]
+	}
+	octave, err := strconv.Atoi(rest)
+	if err != nil {
+		return Pitch{}, fmt.Errorf("pitch %q has no octave: %w", name, err)
+	}
+	p.Octave = octave
+	return p, nil
+}
+
+// mustParsePitch is parsePitch for the pitch names that are hardcoded in this app; a typo there is a programming error.
+func mustParsePitch(name string) Pitch {
+	p, err := parsePitch(name)
+	check_error(err)
+	return p
+}
+
+// String spells the pitch back out, e.g., "C4" or "Eb5".
+func (p Pitch) String() string {
+	acc := ""
+	if p.Accidental > 0 {
+		acc = strings.Repeat("#", p.Accidental)
+	} else if p.Accidental < 0 {
+		acc = strings.Repeat("b", -p.Accidental)
+	}
+	return fmt.Sprintf("%s%s%d", p.Letter, acc, p.Octave)
+}
+
+// MIDI returns the MIDI note number of the pitch; C4 = 60, A4 = 69.
+func (p Pitch) MIDI() int {
+	return (p.Octave+1)*12 + letterSemitones[p.Letter] + p.Accidental
+}
+
+// pitchFromMIDI spells a MIDI note number as a Pitch, using sharps for the black keys.
+func pitchFromMIDI(n int) Pitch {
+	names := []string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	octave := n/12 - 1
+	if n < 0 { // integer division rounds towards zero, so step negative numbers down by hand
+		octave = (n-11)/12 - 1
+	}
+	name := names[((n%12)+12)%12]
+	p := Pitch{Letter: name[0:1], Octave: octave}
+	if len(name) > 1 {
+		p.Accidental = 1
+	}
+	return p
+}
+
+// step counts staff positions (lines and spaces) up from C0; accidentals don't move a note on the staff, so F#4 and F4
+// share a step.
+func (p Pitch) step() int {
+	for i, letter := range noteLetters {
+		if letter == p.Letter {
+			return p.Octave*7 + i
+		}
+	}
+	return p.Octave * 7
+}
+
+// naturalAt is the natural pitch on a staff step, the inverse of step for naturals.
+func naturalAt(step int) Pitch {
+	octave := step / 7
+	if step < 0 {
+		octave = (step - 6) / 7
+	}
+	return Pitch{Letter: noteLetters[step-octave*7], Octave: octave}
+}
+
+// natural drops the accidental: the staff position the pitch is written on.
+func (p Pitch) natural() Pitch { return Pitch{Letter: p.Letter, Octave: p.Octave} }
+
+// name spells the pitch without its octave, e.g. "Eb".
+func (p Pitch) name() string { return strings.TrimRight(p.String(), "0123456789-") }
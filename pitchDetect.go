@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+)
+
+// Monophonic pitch detection with the YIN algorithm (de Cheveigné & Kawahara, 2002).
+// ::: YIN compares a frame with delayed copies of itself; the delay (lag) at which it best matches itself is one period.
+
+// pitchDetector finds the fundamental frequency of a single frame of PCM.
+type pitchDetector struct {
+	SampleRate int
+	Threshold  float64 // YIN's absolute threshold on the normalized difference; 0.10-0.15 is typical
+	MinFreq    float64 // lowest frequency looked for, in Hz
+	MaxFreq    float64 // highest frequency looked for, in Hz
+	MinRMS     float64 // frames quieter than this are treated as silence
+}
+
+// newPitchDetector returns a detector tuned for voices and instruments from about E1 to C7.
+func newPitchDetector(sampleRate int) *pitchDetector {
+	return &pitchDetector{SampleRate: sampleRate, Threshold: 0.12, MinFreq: 40, MaxFreq: 2100, MinRMS: 0.01}
+}
+
+// frameSize is how many samples detect needs: two periods of the lowest frequency, rounded up to a power of two.
+func (d *pitchDetector) frameSize() int {
+	n := 1
+	for n < 2*int(float64(d.SampleRate)/d.MinFreq) {
+		n *= 2
+	}
+	return n
+}
+
+// detect estimates the pitch of frame. confidence runs from 0 (noise) to 1 (a pure periodic tone); ok is false for
+// silence, or when nothing periodic was found in range.
+func (d *pitchDetector) detect(frame []float64) (freq, confidence float64, ok bool) {
+	var energy float64
+	for _, s := range frame {
+		energy += s * s
+	}
+	if len(frame) == 0 || math.Sqrt(energy/float64(len(frame))) < d.MinRMS {
+		return 0, 0, false
+	}
+
+	minLag := int(float64(d.SampleRate) / d.MaxFreq)
+	maxLag := int(float64(d.SampleRate) / d.MinFreq)
+	if minLag < 2 {
+		minLag = 2
+	}
+	if maxLag > len(frame)/2 {
+		maxLag = len(frame) / 2
+	}
+	if maxLag <= minLag {
+		return 0, 0, false
+	}
+	window := len(frame) - maxLag
+
+	// ::: Steps 2 and 3: the difference function, then its cumulative mean normalized form.
+	cmnd := make([]float64, maxLag+1)
+	cmnd[0] = 1
+	var running float64
+	for lag := 1; lag <= maxLag; lag++ {
+		var diff float64
+		for j := 0; j < window; j++ {
+			delta := frame[j] - frame[j+lag]
+			diff += delta * delta
+		}
+		running += diff
+		if running == 0 {
+			cmnd[lag] = 1
+		} else {
+			cmnd[lag] = diff * float64(lag) / running
+		}
+	}
+
+	// Step 4: the first dip under the threshold, followed down to its bottom; failing that, the global minimum.
+	best := -1
+	for lag := minLag; lag <= maxLag; lag++ {
+		if cmnd[lag] < d.Threshold {
+			for lag+1 <= maxLag && cmnd[lag+1] < cmnd[lag] {
+				lag++
+			}
+			best = lag
+			break
+		}
+	}
+	if best == -1 {
+		best = minLag
+		for lag := minLag; lag <= maxLag; lag++ {
+			if cmnd[lag] < cmnd[best] {
+				best = lag
+			}
+		}
+	}
+
+	// Step 5: parabolic interpolation between neighbouring lags for sub-sample accuracy.
+	period := float64(best)
+	if best > 1 && best < maxLag {
+		a, b, c := cmnd[best-1], cmnd[best], cmnd[best+1]
+		if denom := a - 2*b + c; denom != 0 {
+			period += 0.5 * (a - c) / denom
+		}
+	}
+	confidence = math.Max(0, 1-cmnd[best])
+	return float64(d.SampleRate) / period, confidence, confidence >= 1-d.Threshold*2
+}
+
+// nearestPitch returns the pitch closest to freq in the current tuning, and how far off it is in cents.
+func nearestPitch(freq float64) (Pitch, float64) {
+	return currentTuning.nearest(freq)
+}
+
+// pitchReading is a stable pitch heard in a stretch of audio.
+type pitchReading struct {
+	Pitch      Pitch
+	Freq       float64 // average frequency over the stable frames, in Hz
+	Cents      float64 // deviation from Pitch
+	Confidence float64 // average detector confidence over the stable frames
+}
+
+// pitchTracker turns frame-by-frame estimates into a stable reading: the same nearest pitch, with good confidence,
+// for Needed frames in a row. This rides out the scoop at the start of a sung note and the odd octave slip.
+type pitchTracker struct {
+	Needed     int
+	MinConfide float64
+	run        []pitchReading
+}
+
+// newPitchTracker wants about a tenth of a second of steady pitch at typical frame sizes.
+func newPitchTracker() *pitchTracker {
+	return &pitchTracker{Needed: 4, MinConfide: 0.8}
+}
+
+// feed adds one frame's estimate; it returns a reading once the pitch has held steady long enough.
+func (t *pitchTracker) feed(freq, confidence float64, ok bool) (pitchReading, bool) {
+	if !ok || confidence < t.MinConfide {
+		t.run = t.run[:0]
+		return pitchReading{}, false
+	}
+	p, cents := nearestPitch(freq)
+	if len(t.run) > 0 && t.run[0].Pitch.MIDI() != p.MIDI() {
+		t.run = t.run[:0]
+	}
+	t.run = append(t.run, pitchReading{Pitch: p, Freq: freq, Cents: cents, Confidence: confidence})
+	if len(t.run) < t.Needed {
+		return pitchReading{}, false
+	}
+	reading := pitchReading{Pitch: p}
+	for _, r := range t.run {
+		reading.Freq += r.Freq / float64(len(t.run))
+		reading.Confidence += r.Confidence / float64(len(t.run))
+	}
+	_, reading.Cents = nearestPitch(reading.Freq)
+	return reading, true
+}
+
+// errNoPitch is returned when a recording never settles on a pitch.
+var errNoPitch = errors.New("no steady pitch was heard")
+
+// listenForPitch reads src frame by frame (half-overlapping) and returns the first stable pitch it hears.
+func listenForPitch(src pcmSource) (pitchReading, error) {
+	detector := newPitchDetector(src.SampleRate())
+	tracker := newPitchTracker()
+	size := detector.frameSize()
+	hop := size / 2
+	frame := make([]float64, size)
+	filled := 0
+	for {
+		n, err := src.ReadFrames(frame[filled:])
+		filled += n
+		if filled == size {
+			if reading, ok := tracker.feed(detector.detect(frame)); ok {
+				return reading, nil
+			}
+			copy(frame, frame[hop:]) // slide forward by half a frame
+			filled = size - hop
+		}
+		if errors.Is(err, io.EOF) {
+			return pitchReading{}, errNoPitch
+		}
+		if err != nil {
+			return pitchReading{}, err
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// YIN on tones from the synthesizer, from the bottom of the bass staff to above the treble.
+func TestDetectSynthesizedTones(t *testing.T) {
+	tests := []struct {
+		pitch      string
+		sampleRate int
+	}{
+		{"F2", synthSampleRate},
+		{"A2", synthSampleRate},
+		{"E3", synthSampleRate},
+		{"C4", synthSampleRate},
+		{"F#4", synthSampleRate},
+		{"A4", synthSampleRate},
+		{"Bb4", 22050},
+		{"E5", synthSampleRate},
+		{"C6", 48000},
+	}
+	for _, tt := range tests {
+		p := mustParsePitch(tt.pitch)
+		want := frequencyOf(p)
+		d := newPitchDetector(tt.sampleRate)
+		frame := synthesizeTone(want, 0.5, tt.sampleRate)[d.frameSize() : 2*d.frameSize()] // clear of the fade-in
+		freq, confidence, ok := d.detect(frame)
+		if !ok || confidence < 0.9 {
+			t.Errorf("%s: detect gave ok=%v, confidence %.2f", tt.pitch, ok, confidence)
+			continue
+		}
+		if cents := 1200 * math.Log2(freq/want); math.Abs(cents) > 5 {
+			t.Errorf("%s: detected %.2f Hz, %+.1f cents from %.2f Hz", tt.pitch, freq, cents, want)
+		}
+	}
+}
+
+func TestListenForPitch(t *testing.T) {
+	tests := []struct {
+		pitch string
+		cents float64 // how far off the tone is played
+	}{
+		{"G2", 0},
+		{"D3", 0},
+		{"C4", 0},
+		{"A4", 0},
+		{"A4", 20},
+		{"A4", -20},
+		{"G5", 0},
+		{"A5", -10},
+	}
+	for _, tt := range tests {
+		p := mustParsePitch(tt.pitch)
+		freq := frequencyOf(p) * math.Pow(2, tt.cents/1200)
+		reading, err := listenForPitch(newSamplesSource(synthesizeTone(freq, 1, synthSampleRate), synthSampleRate))
+		if err != nil {
+			t.Errorf("%s %+.0f cents: %v", tt.pitch, tt.cents, err)
+			continue
+		}
+		if reading.Pitch != p {
+			t.Errorf("%s %+.0f cents: heard %s", tt.pitch, tt.cents, reading.Pitch)
+		}
+		if math.Abs(reading.Cents-tt.cents) > 3 {
+			t.Errorf("%s %+.0f cents: heard it %+.1f cents off", tt.pitch, tt.cents, reading.Cents)
+		}
+	}
+}
+
+func TestListenForPitchHearsNothing(t *testing.T) {
+	noise := make([]float64, synthSampleRate)
+	seed := uint32(1)
+	for i := range noise { // white noise from a little LCG: there is no period to find
+		seed = seed*1664525 + 1013904223
+		noise[i] = 0.5 * (float64(seed)/math.MaxUint32 - 0.5)
+	}
+	tests := map[string][]float64{
+		"silence":     make([]float64, synthSampleRate),
+		"white noise": noise,
+		"too short":   synthesizeTone(440, 0.01, synthSampleRate),
+	}
+	for name, samples := range tests {
+		if reading, err := listenForPitch(newSamplesSource(samples, synthSampleRate)); !errors.Is(err, errNoPitch) {
+			t.Errorf("%s: got %+v, %v; want errNoPitch", name, reading, err)
+		}
+	}
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Student profiles. ::: Practice-room computers are shared, so everything the game remembers about a student lives in
+// a folder of its own, profiles/<name> in the config directory: the session history, the level and streak, the
+// settings and the achievements. Spaced repetition needs nothing more, as it is worked out from the history. A profile
+// travels between machines as a .zip of its folder.
+
+const (
+	historyFileName  = "history.jsonl"
+	progressFileName = "progress.json"
+	settingsFileName = "settings.json"
+	defaultProfile   = "Default"
+)
+
+// profileFiles are the files a profile folder may hold, and so the only ones an import accepts.
+var profileFiles = []string{historyFileName, progressFileName, settingsFileName, achievementsFileName}
+
+// appDir is the game's folder in the user config directory.
+func appDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "grokMusic"), nil
+}
+
+func profilesDir() (string, error) {
+	dir, err := appDir()
+	return filepath.Join(dir, "profiles"), err
+}
+
+// profile is one student's folder.
+type profile struct {
+	Name string
+	Dir  string
+}
+
+// path is where one of profileFiles lives in the profile.
+func (p profile) path(file string) string { return filepath.Join(p.Dir, file) }
+
+// checkProfileName refuses names that can't be a folder name on every system.
+func checkProfileName(name string) error {
+	switch {
+	case strings.TrimSpace(name) != name || name == "":
+		return fmt.Errorf("a profile name can't be empty or start or end with a space")
+	case name == "." || name == ".." || strings.ContainsAny(name, `/\:*?"<>|`):
+		return fmt.Errorf("a profile name can't contain any of / \\ : * ? \" < > |")
+	case len(name) > 40:
+		return fmt.Errorf("a profile name can have at most 40 characters")
+	}
+	return nil
+}
+
+// listProfiles names the profiles, alphabetically.
+func listProfiles() ([]string, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// openProfile finds an existing profile.
+func openProfile(name string) (profile, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return profile{}, err
+	}
+	p := profile{Name: name, Dir: filepath.Join(dir, name)}
+	if _, err := os.Stat(p.Dir); err != nil {
+		return profile{}, fmt.Errorf("no profile %q: %w", name, err)
+	}
+	return p, nil
+}
+
+// createProfile makes a new, empty profile.
+func createProfile(name string) (profile, error) {
+	if err := checkProfileName(name); err != nil {
+		return profile{}, err
+	}
+	dir, err := profilesDir()
+	if err != nil {
+		return profile{}, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return profile{}, err
+	}
+	p := profile{Name: name, Dir: filepath.Join(dir, name)}
+	if err := os.Mkdir(p.Dir, 0700); os.IsExist(err) {
+		return profile{}, fmt.Errorf("there is already a profile called %q", name)
+	} else if err != nil {
+		return profile{}, err
+	}
+	return p, nil
+}
+
+// renameProfile gives a profile a new name, keeping everything in it.
+func renameProfile(old, name string) (profile, error) {
+	from, err := openProfile(old)
+	if err != nil {
+		return profile{}, err
+	}
+	if err := checkProfileName(name); err != nil {
+		return profile{}, err
+	}
+	to := profile{Name: name, Dir: filepath.Join(filepath.Dir(from.Dir), name)}
+	if _, err := os.Stat(to.Dir); err == nil {
+		return profile{}, fmt.Errorf("there is already a profile called %q", name)
+	}
+	return to, os.Rename(from.Dir, to.Dir)
+}
+
+// deleteProfile removes a profile and all of its history for good.
+func deleteProfile(name string) error {
+	p, err := openProfile(name)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(p.Dir)
+}
+
+// exportProfile writes the profile's files to w as a .zip archive.
+func exportProfile(p profile, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	for _, file := range profileFiles {
+		data, err := os.ReadFile(p.path(file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		f, err := archive.Create(file)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// importProfile makes a new profile called name from an exported archive. Only profileFiles are taken from it; the
+// archive can't write anywhere else. The files are unpacked into a scratch folder that is renamed into place once
+// they are all written, so an import that fails part way leaves no half-made profile behind.
+func importProfile(data []byte, name string) (profile, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return profile{}, fmt.Errorf("not a profile archive: %w", err)
+	}
+	known := map[string]bool{}
+	for _, file := range profileFiles {
+		known[file] = true
+	}
+	if err := checkProfileName(name); err != nil {
+		return profile{}, err
+	}
+	dir, err := profilesDir()
+	if err != nil {
+		return profile{}, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return profile{}, err
+	}
+	p := profile{Name: name, Dir: filepath.Join(dir, name)}
+	if _, err := os.Stat(p.Dir); err == nil {
+		return profile{}, fmt.Errorf("there is already a profile called %q", name)
+	}
+	scratch, err := os.MkdirTemp(filepath.Dir(dir), "import-") // beside profiles/, so it isn't listed as one
+	if err != nil {
+		return profile{}, err
+	}
+	defer os.RemoveAll(scratch) // gone by then if the import worked
+	for _, f := range archive.File {
+		if !known[f.Name] {
+			fmt.Printf("Profile import: skipped %s\n", f.Name)
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return profile{}, err
+		}
+		contents, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return profile{}, err
+		}
+		if err := os.WriteFile(filepath.Join(scratch, f.Name), contents, 0600); err != nil {
+			return profile{}, err
+		}
+	}
+	if err := os.Rename(scratch, p.Dir); err != nil {
+		return profile{}, err
+	}
+	return p, nil
+}
+
+// freeProfileName is name, or name with a number after it if that profile already exists.
+func freeProfileName(name string) string {
+	names, _ := listProfiles()
+	taken := map[string]bool{}
+	for _, n := range names {
+		taken[n] = true
+	}
+	free := name
+	for i := 2; taken[free]; i++ {
+		free = fmt.Sprintf("%s %d", name, i)
+	}
+	return free
+}
+
+// migrateLegacyFiles moves the history and progress kept before there were profiles into the Default profile.
+func migrateLegacyFiles() error {
+	if names, err := listProfiles(); err != nil || len(names) > 0 {
+		return err
+	}
+	dir, err := appDir()
+	if err != nil {
+		return err
+	}
+	p, err := createProfile(defaultProfile)
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{historyFileName, progressFileName} {
+		if err := os.Rename(filepath.Join(dir, file), p.path(file)); err == nil {
+			fmt.Printf("Moved %s into the %s profile\n", file, defaultProfile)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastProfile is the profile used most recently on this machine, or the default one.
+func lastProfile() string {
+	dir, err := appDir()
+	if err != nil {
+		return defaultProfile
+	}
+	name, err := os.ReadFile(filepath.Join(dir, "last_profile"))
+	if err != nil || checkProfileName(string(name)) != nil {
+		return defaultProfile
+	}
+	return string(name)
+}
+
+// rememberProfile records the profile in use, for the next start.
+func rememberProfile(name string) error {
+	dir, err := appDir()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "last_profile"), []byte(name), 0600)
+}
+
+// profileSettings are the settings that follow the student rather than the machine.
+type profileSettings struct {
+	Instrument   string               `json:"instrument"`   // a transposingInstrument's Name
+	Strategy     string               `json:"strategy"`     // a targetStrategy's name
+	A4           float64              `json:"a4"`           // the tuning reference, in Hz
+	Temperament  string               `json:"temperament"`  // one of temperamentNames
+	Key          int                  `json:"key"`          // the temperament's tonic, in semitones above C
+	Measures     int                  `json:"measures"`     // 0 for the free staff
+	Time         string               `json:"time"`         // one of commonTimeSignatures, such as "3/4"
+	SightReading sightReadingSettings `json:"sightReading"` // what the Sight Reading dialog offers first
+}
+
+// sightReadingSettings are sightReadingOptions with the range spelled out, as in "C4".
+type sightReadingSettings struct {
+	Low              string  `json:"low"`
+	High             string  `json:"high"`
+	Length           int     `json:"length"`
+	LeapChance       float64 `json:"leapChance"`
+	AccidentalChance float64 `json:"accidentalChance"`
+}
+
+// settingsOf gathers the game's settings: the globals, and the layout and sight-reading options main keeps.
+func settingsOf(layout measureLayout, sight sightReadingOptions) profileSettings {
+	return profileSettings{
+		Instrument: currentInstrument.Name, Strategy: currentStrategy.name(),
+		A4: currentTuning.A4, Temperament: currentTuning.Temperament.String(), Key: currentTuning.Key,
+		Measures: layout.Measures, Time: layout.Time.String(),
+		SightReading: sightReadingSettings{Low: sight.Low.String(), High: sight.High.String(), Length: sight.Length,
+			LeapChance: sight.LeapChance, AccidentalChance: sight.AccidentalChance},
+	}
+}
+
+// loadSettings reads a profile's settings; a missing file, or a setting missing from it, is the default.
+func loadSettings(p profile) (profileSettings, error) {
+	s := settingsOf(newGrandStaffLayout(0, commonTimeSignatures[2]), defaultSightReadingOptions)
+	s.Instrument, s.Strategy = transposingInstruments[0].Name, targetStrategies[0].name()
+	s.A4, s.Temperament, s.Key = 440, equalTemperament.String(), 0
+	data, err := os.ReadFile(p.path(settingsFileName))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	return s, json.Unmarshal(data, &s)
+}
+
+func (s profileSettings) save(p profile) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.path(settingsFileName), data, 0600)
+}
+
+// apply makes these the game's settings, returning the layout and sight-reading options for main to take on. A name
+// or a value this version doesn't know leaves that setting as it was.
+func (s profileSettings) apply(layout measureLayout, sight sightReadingOptions) (measureLayout, sightReadingOptions) {
+	for _, inst := range transposingInstruments {
+		if inst.Name == s.Instrument {
+			currentInstrument = inst
+		}
+	}
+	for _, strategy := range targetStrategies {
+		if strategy.name() == s.Strategy {
+			currentStrategy = strategy
+		}
+	}
+	for t, name := range temperamentNames {
+		if name == s.Temperament && s.A4 > 0 && s.Key >= 0 && s.Key < 12 {
+			currentTuning = tuning{A4: s.A4, Temperament: temperament(t), Key: s.Key}
+		}
+	}
+	for _, ts := range commonTimeSignatures {
+		if ts.String() == s.Time && s.Measures >= 0 && s.Measures <= 4 { // as many as the Measures dialog offers
+			layout = newGrandStaffLayout(s.Measures, ts)
+		}
+	}
+	low, lowErr := parsePitch(s.SightReading.Low)
+	high, highErr := parsePitch(s.SightReading.High)
+	if lowErr == nil && highErr == nil && low.step() <= high.step() && s.SightReading.Length > 0 {
+		sight = sightReadingOptions{Low: low, High: high, Length: s.SightReading.Length,
+			LeapChance: s.SightReading.LeapChance, AccidentalChance: s.SightReading.AccidentalChance}
+	}
+	return layout, sight
+}
+
+// showProfilePicker lists the profiles to pick one from, with the buttons to create, rename, delete, export and import
+// them. The profile in use (current) can be renamed but not deleted; onUse (run holding gameMu) gets the chosen
+// profile, and also the current one again after a rename. Before the profile in use is renamed, flush (also holding
+// gameMu) writes out whatever the game still has to save into its folder.
+func showProfilePicker(parentWindow fyne.Window, current string, onUse func(profile), flush func()) {
+	var names []string
+	selected := ""
+	list := widget.NewList(
+		func() int { return len(names) },
+		func() fyne.CanvasObject { return widget.NewLabel("A profile name") },
+		func(id widget.ListItemID, item fyne.CanvasObject) { item.(*widget.Label).SetText(names[id]) })
+	list.OnSelected = func(id widget.ListItemID) { selected = names[id] }
+	reload := func(pick string) {
+		var err error
+		if names, err = listProfiles(); err != nil {
+			dialog.ShowError(err, parentWindow)
+		}
+		list.Refresh()
+		list.UnselectAll()
+		selected = ""
+		for i, name := range names {
+			if name == pick {
+				list.Select(i)
+			}
+		}
+	}
+	// askName asks for a profile name, starting from suggested, and hands it to then.
+	askName := func(title, suggested string, then func(string)) {
+		entry := widget.NewEntry()
+		entry.SetText(suggested)
+		dialog.ShowForm(title, "OK", "Cancel", []*widget.FormItem{widget.NewFormItem("Name", entry)}, func(ok bool) {
+			if ok {
+				then(entry.Text)
+			}
+		}, parentWindow)
+	}
+
+	newButton := widget.NewButton("New...", func() {
+		askName("New Profile", "", func(name string) {
+			if _, err := createProfile(name); err != nil {
+				dialog.ShowError(err, parentWindow)
+			}
+			reload(name)
+		})
+	})
+	renameButton := widget.NewButton("Rename...", func() {
+		old := selected
+		if old == "" {
+			return
+		}
+		askName("Rename Profile", old, func(name string) {
+			rename := func() {
+				p, err := renameProfile(old, name)
+				if err != nil {
+					dialog.ShowError(err, parentWindow)
+					return
+				}
+				if old == current {
+					current = name
+					onUse(p)
+				}
+				reload(name)
+			}
+			if old != current {
+				rename()
+				return
+			}
+			withGame(func() { // the game writes nothing while the folder moves: the pending round goes in first
+				flush()
+				rename()
+			})
+		})
+	})
+	deleteButton := widget.NewButton("Delete", func() {
+		name := selected
+		switch {
+		case name == "":
+			return
+		case name == current:
+			dialog.ShowInformation("Delete Profile", "The profile in use can't be deleted; switch to another one first.", parentWindow)
+			return
+		}
+		dialog.ShowConfirm("Delete Profile", fmt.Sprintf("Delete %s and all of its history for good?", name), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := deleteProfile(name); err != nil {
+				dialog.ShowError(err, parentWindow)
+			}
+			reload(current)
+		}, parentWindow)
+	})
+	exportButton := widget.NewButton("Export...", func() {
+		p, err := openProfile(selected)
+		if err != nil {
+			return
+		}
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			if writer == nil { // cancelled
+				return
+			}
+			defer writer.Close()
+			if err := exportProfile(p, writer); err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			fmt.Printf("Exported profile %s to %s\n", p.Name, writer.URI().Path())
+		}, parentWindow)
+		save.SetFileName(p.Name + ".zip")
+		save.Show()
+	})
+	importButton := widget.NewButton("Import...", func() {
+		open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			if reader == nil { // cancelled
+				return
+			}
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			p, err := importProfile(data, freeProfileName(strings.TrimSuffix(reader.URI().Name(), ".zip")))
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+			}
+			reload(p.Name)
+		}, parentWindow)
+		open.SetFilter(storage.NewExtensionFileFilter([]string{".zip"}))
+		open.Show()
+	})
+
+	buttons := container.NewHBox(newButton, renameButton, deleteButton, exportButton, importButton)
+	content := container.NewBorder(widget.NewLabel("Who's practicing?"), buttons, nil, nil, list)
+	picker := dialog.NewCustomConfirm("Student Profile", "Use", "Keep "+current, content, func(use bool) {
+		name := current
+		if use && selected != "" {
+			name = selected
+		}
+		p, err := openProfile(name)
+		if err != nil { // e.g. the current profile is gone from the disk
+			if p, err = createProfile(name); err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+		}
+		withGame(func() { onUse(p) })
+	}, parentWindow)
+	picker.Resize(fyne.NewSize(560, 420))
+	reload(current)
+	picker.Show()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// restoreSettings puts the global settings back once a test that applies some is over.
+func restoreSettings(t *testing.T) {
+	instrument, strategy, tu := currentInstrument, currentStrategy, currentTuning
+	t.Cleanup(func() { currentInstrument, currentStrategy, currentTuning = instrument, strategy, tu })
+}
+
+// Every setting a profile keeps comes back from its file as it went in.
+func TestProfileSettingsRoundTrip(t *testing.T) {
+	restoreSettings(t)
+	p := profile{Name: "test", Dir: t.TempDir()}
+	currentInstrument, currentStrategy = transposingInstruments[2], targetStrategies[1]
+	currentTuning = tuning{A4: 415, Temperament: meantone, Key: 7}
+	layout := newGrandStaffLayout(3, commonTimeSignatures[5])
+	sight := sightReadingOptions{Low: mustParsePitch("G3"), High: mustParsePitch("E5"), Length: 16, LeapChance: 0.4, AccidentalChance: 0.15}
+	if err := settingsOf(layout, sight).save(p); err != nil {
+		t.Fatal(err)
+	}
+
+	currentInstrument, currentStrategy, currentTuning = transposingInstruments[0], targetStrategies[0], tuning{A4: 440}
+	s, err := loadSettings(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotLayout, gotSight := s.apply(newGrandStaffLayout(0, commonTimeSignatures[2]), defaultSightReadingOptions)
+	if currentInstrument != transposingInstruments[2] || currentStrategy != targetStrategies[1] {
+		t.Errorf("got %s and %s back", currentInstrument.Name, currentStrategy.name())
+	}
+	if currentTuning != (tuning{A4: 415, Temperament: meantone, Key: 7}) {
+		t.Errorf("got tuning %+v back", currentTuning)
+	}
+	if gotLayout != layout {
+		t.Errorf("got layout %+v back, want %+v", gotLayout, layout)
+	}
+	if gotSight != sight {
+		t.Errorf("got sight reading %+v back, want %+v", gotSight, sight)
+	}
+}
+
+func TestProfileSettingsApplyKeepsWhatItDoesntKnow(t *testing.T) {
+	restoreSettings(t)
+	currentTuning = tuning{A4: 442, Temperament: justIntonation, Key: 2}
+	layout := newGrandStaffLayout(2, commonTimeSignatures[1])
+	tests := map[string]profileSettings{
+		"unknown temperament":     {A4: 440, Temperament: "Werckmeister III", Time: "5/4", Measures: 2},
+		"no reference":            {A4: 0, Temperament: "Equal", Time: "4/4", Measures: 9},
+		"key out of range":        {A4: 440, Temperament: "Equal", Key: 12, Time: "4/4", Measures: -1},
+		"sight range unknown":     {SightReading: sightReadingSettings{Low: "H4", High: "C5", Length: 8}},
+		"sight range upside down": {SightReading: sightReadingSettings{Low: "C5", High: "C4", Length: 8}},
+		"no sight notes":          {SightReading: sightReadingSettings{Low: "C4", High: "C5"}},
+	}
+	for name, s := range tests {
+		gotLayout, gotSight := s.apply(layout, defaultSightReadingOptions)
+		if currentTuning != (tuning{A4: 442, Temperament: justIntonation, Key: 2}) || gotLayout != layout || gotSight != defaultSightReadingOptions {
+			t.Errorf("%s: changed the settings to %+v, %+v, %+v", name, currentTuning, gotLayout, gotSight)
+		}
+	}
+}
+
+// An old settings file, from before the tuning, measures and sight reading were kept, loads with the defaults.
+func TestLoadSettingsFillsInDefaults(t *testing.T) {
+	p := profile{Name: "old", Dir: t.TempDir()}
+	if err := os.WriteFile(p.path(settingsFileName), []byte(`{"instrument": "Bb clarinet / Bb trumpet"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadSettings(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := profileSettings{Instrument: "Bb clarinet / Bb trumpet", Strategy: targetStrategies[0].name(),
+		A4: 440, Temperament: "Equal", Time: "4/4",
+		SightReading: sightReadingSettings{Low: "C4", High: "G5", Length: 12, LeapChance: 0.25}}
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+}
+
+func TestImportProfile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	from := profile{Name: "from", Dir: t.TempDir()}
+	if err := os.WriteFile(from.path(historyFileName), []byte("{\"mode\":\"find\"}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(from.path(progressFileName), []byte(`{"level":2}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var archive bytes.Buffer
+	if err := exportProfile(from, &archive); err != nil {
+		t.Fatal(err)
+	}
+	good := archive.Bytes()
+	corrupt := bytes.Replace(bytes.Clone(good), []byte(`{"level":2}`), []byte(`{"level":9}`), 1) // fails its checksum
+
+	tests := []struct {
+		name    string
+		data    []byte
+		profile string
+		ok      bool
+	}{
+		{"an export", good, "Ada", true},
+		{"the same name again", good, "Ada", false},
+		{"a bad name", good, "A/B", false},
+		{"not a zip", []byte("PK? no"), "Grace", false},
+		{"a file cut short", good[:len(good)/2], "Grace", false},
+		{"a corrupt file", corrupt, "Grace", false},
+	}
+	for _, tt := range tests {
+		p, err := importProfile(tt.data, tt.profile)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if tt.ok {
+			if data, _ := os.ReadFile(p.path(progressFileName)); string(data) != `{"level":2}` {
+				t.Errorf("%s: imported progress %q", tt.name, data)
+			}
+		}
+	}
+	names, err := listProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"Ada"}) {
+		t.Errorf("profiles after the imports: %q, want only Ada", names)
+	}
+	dir, _ := appDir()
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "import-*")); len(leftovers) > 0 {
+		t.Errorf("left behind %q", leftovers)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Levels and streaks. ::: Find-the-note rounds start on the treble staff alone and open up level by level: ledger
+// notes, the bass clef, the whole Grand Staff, then sharps and flats. A level is passed with promoteAfter perfect Checks
+// in a row; one Check that isn't perfect starts the streak over. Progress is saved as JSON in the student's profile.
+
+// level is what find-the-note rounds may ask for.
+type level struct {
+	Name        string
+	Treble      bool // notes from C4 up
+	Bass        bool // notes below C4
+	Ledger      bool // notes off the five lines of their staff: C4, D4, G5 and A5; B3 and F2
+	Accidentals bool // targets may be sharp or flat, placed with the accidental choice
+}
+
+var levels = []level{
+	{Name: "Treble staff", Treble: true},
+	{Name: "Treble staff with ledger notes", Treble: true, Ledger: true},
+	{Name: "Bass staff", Bass: true},
+	{Name: "Grand Staff", Treble: true, Bass: true, Ledger: true},
+	{Name: "Grand Staff with sharps and flats", Treble: true, Bass: true, Ledger: true, Accidentals: true},
+}
+
+// promoteAfter is how many perfect Checks in a row pass a level.
+const promoteAfter = 5
+
+// Staff steps (see Pitch.step) of the outer lines of each staff.
+var (
+	trebleBottomLine = mustParsePitch("E4").step()
+	trebleTopLine    = mustParsePitch("F5").step()
+	bassBottomLine   = mustParsePitch("G2").step()
+	bassTopLine      = mustParsePitch("A3").step()
+)
+
+// includes reports whether a staff position can be a target at this level.
+func (l level) includes(pos NotePosition) bool {
+	step := mustParsePitch(pos.Pitch).step()
+	if step >= trebleLowStep {
+		return l.Treble && (l.Ledger || step >= trebleBottomLine && step <= trebleTopLine)
+	}
+	return l.Bass && (l.Ledger || step >= bassBottomLine && step <= bassTopLine)
+}
+
+// progress is a student's place in the levels.
+type progress struct {
+	Level      int `json:"level"`    // the level being played, an index into levels
+	Unlocked   int `json:"unlocked"` // the highest level reached; any level up to it can be chosen
+	Streak     int `json:"streak"`   // perfect Checks in a row
+	BestStreak int `json:"best_streak"`
+	Checks     int `json:"checks"`
+	Perfect    int `json:"perfect"`
+}
+
+// checked counts a Check of a find-the-note round, and reports whether it passed the level. Passing the highest
+// unlocked level unlocks and moves on to the next; passing a lower one (chosen again for practice) just restarts the
+// streak there.
+func (p *progress) checked(perfect bool) (promoted bool) {
+	p.Checks++
+	if !perfect {
+		p.Streak = 0
+		return false
+	}
+	p.Perfect++
+	p.Streak++
+	if p.Streak > p.BestStreak {
+		p.BestStreak = p.Streak
+	}
+	if p.Streak < promoteAfter || p.Level+1 >= len(levels) {
+		return false
+	}
+	p.Streak = 0
+	if p.Level == p.Unlocked {
+		p.Unlocked++
+		p.Level++
+		return true
+	}
+	return false
+}
+
+// header is the line shown above the instructions.
+func (p progress) header() string {
+	streak := fmt.Sprintf("streak %d/%d", p.Streak, promoteAfter)
+	if p.Level+1 >= len(levels) {
+		streak = fmt.Sprintf("streak %d", p.Streak)
+	}
+	return fmt.Sprintf("Level %d of %d: %s · %s · best %d · %d of %d Checks perfect",
+		p.Level+1, len(levels), levels[p.Level].Name, streak, p.BestStreak, p.Perfect, p.Checks)
+}
+
+// loadProgress reads saved progress; a missing file is a new student at the first level.
+func loadProgress(path string) (progress, error) {
+	var p progress
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return progress{}, err
+	}
+	if p.Unlocked >= len(levels) { // saved by a version with more levels
+		p.Unlocked = len(levels) - 1
+	}
+	if p.Unlocked < 0 { // or edited by hand
+		p.Unlocked = 0
+	}
+	if p.Level > p.Unlocked {
+		p.Level = p.Unlocked
+	}
+	if p.Level < 0 {
+		p.Level = 0
+	}
+	return p, nil
+}
+
+// save writes the progress out whole, through a temporary file so a crash can't leave half of it.
+func (p progress) save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// showLevelDialog picks any unlocked level to play; changing level starts the streak over.
+func showLevelDialog(parentWindow fyne.Window, p *progress, onChange func()) {
+	var names []string
+	for i := 0; i <= p.Unlocked; i++ {
+		names = append(names, fmt.Sprintf("%d. %s", i+1, levels[i].Name))
+	}
+	choice := widget.NewSelect(names, nil)
+	choice.SetSelectedIndex(p.Level)
+	dialog.ShowForm("Level", "Play", "Cancel", []*widget.FormItem{widget.NewFormItem("Level", choice)},
+		func(ok bool) {
+			gameMu.Lock()
+			defer gameMu.Unlock()
+			if ok && choice.SelectedIndex() != p.Level {
+				p.Level, p.Streak = choice.SelectedIndex(), 0
+				fmt.Printf("Level: %s\n", levels[p.Level].Name)
+				onChange()
+			}
+		}, parentWindow)
+}
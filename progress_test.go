@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// repeat is n Checks, all perfect or none.
+func repeat(n int, perfect bool) []bool {
+	checks := make([]bool, n)
+	for i := range checks {
+		checks[i] = perfect
+	}
+	return checks
+}
+
+func TestProgressChecked(t *testing.T) {
+	last := len(levels) - 1
+	tests := []struct {
+		name     string
+		from     progress
+		checks   []bool
+		want     progress
+		promoted bool // by the last Check
+	}{
+		{"a perfect Check", progress{}, repeat(1, true),
+			progress{Streak: 1, BestStreak: 1, Checks: 1, Perfect: 1}, false},
+		{"a streak passes the level", progress{}, repeat(promoteAfter, true),
+			progress{Level: 1, Unlocked: 1, BestStreak: promoteAfter, Checks: promoteAfter, Perfect: promoteAfter}, true},
+		{"a miss starts the streak over", progress{Streak: 4, BestStreak: 4}, []bool{false},
+			progress{BestStreak: 4, Checks: 1}, false},
+		{"a miss just short of passing", progress{}, append(repeat(promoteAfter-1, true), false),
+			progress{BestStreak: promoteAfter - 1, Checks: promoteAfter, Perfect: promoteAfter - 1}, false},
+		{"replaying a lower level", progress{Level: 0, Unlocked: 2}, repeat(promoteAfter, true),
+			progress{Level: 0, Unlocked: 2, BestStreak: promoteAfter, Checks: promoteAfter, Perfect: promoteAfter}, false},
+		{"the last level has nowhere to go", progress{Level: last, Unlocked: last}, repeat(promoteAfter+1, true),
+			progress{Level: last, Unlocked: last, Streak: promoteAfter + 1, BestStreak: promoteAfter + 1, Checks: promoteAfter + 1, Perfect: promoteAfter + 1}, false},
+	}
+	for _, tt := range tests {
+		p := tt.from
+		promoted := false
+		for _, perfect := range tt.checks {
+			promoted = p.checked(perfect)
+		}
+		if p != tt.want || promoted != tt.promoted {
+			t.Errorf("%s: got %+v, promoted %v; want %+v, promoted %v", tt.name, p, promoted, tt.want, tt.promoted)
+		}
+	}
+}
+
+// Saved levels outside the levels there are come back inside them.
+func TestLoadProgressClamps(t *testing.T) {
+	last := len(levels) - 1
+	tests := []struct {
+		saved           string
+		level, unlocked int
+	}{
+		{`{"level": 2, "unlocked": 3}`, 2, 3},
+		{`{"level": 9, "unlocked": 9}`, last, last},
+		{`{"level": 4, "unlocked": 1}`, 1, 1},
+		{`{"level": -1, "unlocked": -3}`, 0, 0},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), progressFileName)
+		if err := os.WriteFile(path, []byte(tt.saved), 0600); err != nil {
+			t.Fatal(err)
+		}
+		p, err := loadProgress(path)
+		if err != nil || p.Level != tt.level || p.Unlocked != tt.unlocked {
+			t.Errorf("%s: got level %d of %d unlocked (err %v); want %d of %d", tt.saved, p.Level, p.Unlocked, err, tt.level, tt.unlocked)
+		}
+	}
+	if p, err := loadProgress(filepath.Join(t.TempDir(), "missing.json")); err != nil || p != (progress{}) {
+		t.Errorf("no file: got %+v, %v; want a new student", p, err)
+	}
+}
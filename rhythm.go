@@ -0,0 +1,197 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// The rhythm model: note values, dots, ties and rests, and the scoring of taps against them.
+// ::: Positions within a measure are measured in quarter-note beats; a dotted eighth is 0.75, a half note 2.
+
+// noteValue is a note's written value, as the denominator of its fraction of a whole note.
+type noteValue int
+
+const (
+	wholeNote     noteValue = 1
+	halfNote      noteValue = 2
+	quarterNote   noteValue = 4
+	eighthNote    noteValue = 8
+	sixteenthNote noteValue = 16
+)
+
+// beats returns the length of an undotted value in quarter-note beats.
+func (v noteValue) beats() float64 { return 4 / float64(v) }
+
+// rhythmEvent is one note or rest of a measure.
+type rhythmEvent struct {
+	Value  noteValue
+	Dotted bool // half as long again
+	Rest   bool
+	Tied   bool // tied to the next note, which is held rather than struck again
+}
+
+// beats returns how long the event lasts, in quarter-note beats.
+func (e rhythmEvent) beats() float64 {
+	if e.Dotted {
+		return e.Value.beats() * 1.5
+	}
+	return e.Value.beats()
+}
+
+// rhythmMeasure is one bar of notes and rests.
+type rhythmMeasure []rhythmEvent
+
+// starts returns the beat on which each event begins.
+func (m rhythmMeasure) starts() []float64 {
+	starts := make([]float64, len(m))
+	var at float64
+	for i, e := range m {
+		starts[i] = at
+		at += e.beats()
+	}
+	return starts
+}
+
+// onsets returns the beats on which the student should tap: every note, except one that continues a tie.
+func (m rhythmMeasure) onsets() []float64 {
+	var onsets []float64
+	starts := m.starts()
+	for i, e := range m {
+		if e.Rest || (i > 0 && m[i-1].Tied && !m[i-1].Rest) {
+			continue
+		}
+		onsets = append(onsets, starts[i])
+	}
+	return onsets
+}
+
+// rhythmChoices are the event lengths the generator may use; every one keeps notes on a sixteenth-note grid.
+var rhythmChoices = []rhythmEvent{
+	{Value: wholeNote}, {Value: halfNote, Dotted: true}, {Value: halfNote}, {Value: quarterNote, Dotted: true},
+	{Value: quarterNote}, {Value: eighthNote, Dotted: true}, {Value: eighthNote}, {Value: sixteenthNote},
+}
+
+// generateRhythmMeasure fills a bar of beatsPerBar quarter-note beats. shortest limits how busy it gets
+// (quarterNote for beginners, sixteenthNote for the brave).
+func generateRhythmMeasure(rng *rand.Rand, beatsPerBar float64, shortest noteValue) rhythmMeasure {
+	var m rhythmMeasure
+	remaining := beatsPerBar
+	for remaining > 0 {
+		var fits []rhythmEvent
+		for _, c := range rhythmChoices {
+			if c.Value > shortest || c.beats() > remaining {
+				continue
+			}
+			pos := beatsPerBar - remaining
+			if c.beats() < 1 && math.Floor(pos) != math.Floor(pos+c.beats()-0.001) {
+				continue // short notes don't straddle a beat, which keeps the bar readable
+			}
+			fits = append(fits, c)
+		}
+		e := rhythmEvent{Value: sixteenthNote, Rest: true}
+		if len(fits) > 0 {
+			e = fits[rng.Intn(len(fits))]
+			e.Rest = rng.Float64() < 0.15
+		} else { // a gap shorter than `shortest` (after a dotted note): fill it with the longest rest that fits
+			for v := wholeNote; v <= sixteenthNote; v *= 2 {
+				if v.beats() <= remaining {
+					e.Value = v
+					break
+				}
+			}
+		}
+		m = append(m, e)
+		remaining -= e.beats()
+	}
+	for i := 0; i+1 < len(m); i++ { // an occasional tie between neighbouring notes
+		if !m[i].Rest && !m[i+1].Rest && rng.Float64() < 0.1 {
+			m[i].Tied = true
+		}
+	}
+	return m
+}
+
+// tapResult is how one expected onset was played.
+type tapResult struct {
+	Offset time.Duration // tap time minus the exact time; negative is early
+	Hit    bool          // false when no tap came close enough
+}
+
+// scoreTaps pairs each expected onset with the nearest unused tap within window. It returns one result per onset,
+// plus how many taps matched nothing. expected must be in time order.
+func scoreTaps(expected []time.Time, taps []time.Time, window time.Duration) ([]tapResult, int) {
+	used := make([]bool, len(taps))
+	results := make([]tapResult, len(expected))
+	matched := 0
+	for i, want := range expected {
+		best := -1
+		for j, tap := range taps {
+			if used[j] {
+				continue
+			}
+			off := tap.Sub(want)
+			if off < -window || off > window {
+				continue
+			}
+			if best == -1 || absDuration(off) < absDuration(taps[best].Sub(want)) {
+				best = j
+			}
+		}
+		if best >= 0 {
+			used[best] = true
+			results[i] = tapResult{Offset: taps[best].Sub(want), Hit: true}
+			matched++
+		}
+	}
+	return results, len(taps) - matched
+}
+
+// measureAccuracy scores one measure from 0 to 1: each onset earns up to 1 (less the further off it was),
+// and each stray tap counts against it like a missed onset.
+func measureAccuracy(results []tapResult, extras int, window time.Duration) float64 {
+	if len(results)+extras == 0 {
+		return 1
+	}
+	var sum float64
+	for _, r := range results {
+		if r.Hit {
+			sum += 1 - float64(absDuration(r.Offset))/float64(window)
+		}
+	}
+	return sum / float64(len(results)+extras)
+}
+
+// scoreExercise scores the taps of a whole exercise; expected holds each measure's onsets, in time order. A tap goes to
+// the measure of the onset nearest to it, wherever the bar lines fall, so a late tap on a measure's last note still
+// counts for that measure. It returns each measure's results and extra taps, as scoreTaps does for one.
+func scoreExercise(expected [][]time.Time, taps []time.Time, window time.Duration) ([][]tapResult, []int) {
+	byMeasure := make([][]time.Time, len(expected))
+	for _, tap := range taps {
+		nearest, closest := -1, time.Duration(0)
+		for m, onsets := range expected {
+			for _, want := range onsets {
+				if off := absDuration(tap.Sub(want)); nearest == -1 || off < closest {
+					nearest, closest = m, off
+				}
+			}
+		}
+		if nearest >= 0 {
+			byMeasure[nearest] = append(byMeasure[nearest], tap)
+		}
+	}
+	results := make([][]tapResult, len(expected))
+	extras := make([]int, len(expected))
+	for m, onsets := range expected {
+		results[m], extras[m] = scoreTaps(onsets, byMeasure[m], window)
+	}
+	return results, extras
+}
+
+// absDuration is abs for time.Duration.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
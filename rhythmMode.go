@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The rhythm reading mode. ::: A couple of measures are drawn on a single-line staff; after a one-bar count-in the
+// student taps each note (space bar, or a click on the staff) along with the metronome, and every tap is scored by how
+// early or late it was.
+
+const (
+	rhythmStaffWidth  = 900
+	rhythmStaffHeight = 160
+	rhythmLineY       = 90  // the single staff line
+	rhythmLeft        = 40  // where the first measure starts
+	rhythmMeasures    = 2   // measures per exercise
+	rhythmBeatsPerBar = 4   // 4/4 for now
+	rhythmBarWidth    = 420 // width of one measure in pixels
+)
+
+// rhythmBeatX is the x-coordinate of a beat position within a measure.
+func rhythmBeatX(measure int, beat float64) float32 {
+	return float32(rhythmLeft + measure*rhythmBarWidth + 30 + int(beat*(rhythmBarWidth-50)/rhythmBeatsPerBar))
+}
+
+// drawRhythmStaff draws the line, the barlines, and every note and rest of the measures.
+func drawRhythmStaff(bars []rhythmMeasure) []fyne.CanvasObject {
+	black := color.Black
+	objects := []fyne.CanvasObject{canvas.NewRectangle(color.RGBA{R: 25, G: 200, B: 25, A: 155})}
+	objects[0].Resize(fyne.NewSize(rhythmStaffWidth, rhythmStaffHeight))
+
+	objects = append(objects, newStroke(black, rhythmLeft, rhythmLineY, float32(rhythmLeft+len(bars)*rhythmBarWidth), rhythmLineY, 2))
+	for m := 0; m <= len(bars); m++ {
+		x := float32(rhythmLeft + m*rhythmBarWidth)
+		objects = append(objects, newStroke(black, x, rhythmLineY-20, x, rhythmLineY+20, 2))
+	}
+
+	metrics := metricsForGap(12)
+	for m, bar := range bars {
+		starts := bar.starts()
+		for _, group := range beamGroups(bar) {
+			var glyphs []noteGlyph
+			for _, i := range group {
+				e := bar[i]
+				glyphs = append(glyphs, noteGlyph{Value: e.Value, Dotted: e.Dotted, Rest: e.Rest,
+					X: rhythmBeatX(m, starts[i]), Y: rhythmLineY, StemUp: true, OnLine: true})
+			}
+			objects = append(objects, drawBeamedGroup(glyphs, metrics, black)...)
+		}
+		for i, e := range bar {
+			if e.Tied && i+1 < len(bar) { // a shallow curve under the two heads, as two short strokes
+				x, next := rhythmBeatX(m, starts[i]), rhythmBeatX(m, starts[i+1])
+				mid := (x + next) / 2
+				objects = append(objects,
+					newStroke(black, x+4, rhythmLineY+10, mid, rhythmLineY+18, 1.5),
+					newStroke(black, mid, rhythmLineY+18, next-4, rhythmLineY+10, 1.5),
+				)
+			}
+		}
+	}
+	return objects
+}
+
+// rhythmChart draws one bar per measure, as tall as its accuracy.
+func rhythmChart(accuracies []float64) fyne.CanvasObject {
+	chart := container.NewHBox()
+	for m, acc := range accuracies {
+		const height = 100
+		bar := canvas.NewRectangle(color.RGBA{R: 0, G: 120, B: 220, A: 255})
+		if acc < 0.6 {
+			bar.FillColor = color.RGBA{R: 220, G: 60, B: 0, A: 255}
+		}
+		bar.Resize(fyne.NewSize(40, float32(acc*height)))
+		bar.Move(fyne.NewPos(10, float32(height-acc*height)))
+		column := container.NewWithoutLayout(bar)
+		chart.Add(container.NewVBox(
+			container.NewGridWrap(fyne.NewSize(60, height), column),
+			widget.NewLabel(fmt.Sprintf("Bar %d: %.0f%%", m+1, acc*100)),
+		))
+	}
+	return chart
+}
+
+// showRhythmWindow opens the rhythm reading mode in its own window. The bars are drawn from rng, holding gameMu.
+func showRhythmWindow(a fyne.App, clk clock, rng *rand.Rand) {
+	w := a.NewWindow("Rhythm Reading")
+
+	staff := container.NewWithoutLayout()
+	status := widget.NewLabel("Press Start, listen to the one-bar count-in, then tap each note with the space bar or a click")
+	results := container.NewVBox()
+	bpm := widget.NewSlider(40, 160)
+	bpm.Step = 1
+	bpm.SetValue(80)
+	bpmLabel := widget.NewLabel("80 BPM")
+	bpm.OnChanged = func(v float64) { bpmLabel.SetText(fmt.Sprintf("%.0f BPM", v)) }
+	shortest := widget.NewSelect([]string{"Quarter notes", "Eighth notes", "Sixteenth notes"}, nil)
+	shortest.SetSelectedIndex(1)
+
+	var (
+		bars     []rhythmMeasure
+		expected [][]time.Time // per measure, the exact time of each onset
+		taps     []time.Time
+		running  bool
+		closing  bool // past the last bar line, waiting out the window for a late tap on the last note
+		cursor   *canvas.Line
+		start    time.Time     // when the count-in began
+		beat     time.Duration // length of one beat at the chosen tempo
+		window   time.Duration // how early or late a tap may be and still count
+	)
+	// The state above is shared by the Start button and the taps, on Fyne's goroutine, and the metronome's ticks, on
+	// its own: all three read and change it holding gameMu (see clock.go).
+	tap := func() {
+		now := clk.Now()
+		withGame(func() {
+			if running {
+				taps = append(taps, now)
+			}
+		})
+	}
+	w.Canvas().SetOnTypedKey(func(ev *fyne.KeyEvent) {
+		if ev.Name == fyne.KeySpace {
+			tap()
+		}
+	})
+	tapArea := canvas.NewRectangle(color.Transparent)
+	tapArea.Resize(fyne.NewSize(rhythmStaffWidth, rhythmStaffHeight))
+	tappable := &TappableCanvas{CanvasObject: tapArea, OnTapped: func(*fyne.PointEvent) { tap() }}
+
+	var met *metronome
+	// finish scores the taps. It runs a tolerance window after the last bar line, so that a late tap on the last note
+	// is still in.
+	finish := func() {
+		running = false
+		countIn := start.Add(rhythmBeatsPerBar*beat - window)
+		var counted []time.Time // taps during the count-in, well before the first bar, are just the student joining in
+		for _, t := range taps {
+			if !t.Before(countIn) {
+				counted = append(counted, t)
+			}
+		}
+		allScored, allExtras := scoreExercise(expected, counted, window)
+		var accuracies []float64
+		var details []string
+		for m := range expected {
+			scored, extras := allScored[m], allExtras[m]
+			accuracies = append(accuracies, measureAccuracy(scored, extras, window))
+			for i, r := range scored {
+				beatNo := bars[m].onsets()[i] + 1
+				switch {
+				case !r.Hit:
+					details = append(details, fmt.Sprintf("Bar %d, beat %g: missed", m+1, beatNo))
+				case r.Offset < 0:
+					details = append(details, fmt.Sprintf("Bar %d, beat %g: %d ms early", m+1, beatNo, -r.Offset.Milliseconds()))
+				default:
+					details = append(details, fmt.Sprintf("Bar %d, beat %g: %d ms late", m+1, beatNo, r.Offset.Milliseconds()))
+				}
+			}
+			if extras > 0 {
+				details = append(details, fmt.Sprintf("Bar %d: %d extra taps", m+1, extras))
+			}
+		}
+		status.SetText("Done! Accuracy per measure:")
+		results.Objects = []fyne.CanvasObject{rhythmChart(accuracies), widget.NewLabel(strings.Join(details, "\n"))}
+		results.Refresh()
+		fmt.Println(strings.Join(details, "\n"))
+	}
+
+	met = newMetronome(clk, bpm.Value, rhythmBeatsPerBar, 1, func(tick beatTick) {
+		withGame(func() {
+			if !running {
+				return
+			}
+			bar := tick.Index/rhythmBeatsPerBar - 1 // bar -1 is the count-in
+			switch {
+			case bar < 0:
+				status.SetText(fmt.Sprintf("Count-in: %d", tick.Beat+1))
+			case bar < len(bars):
+				status.SetText(fmt.Sprintf("Bar %d, beat %d", bar+1, tick.Beat+1))
+				x := rhythmBeatX(bar, float64(tick.Beat))
+				cursor.Position1, cursor.Position2 = fyne.NewPos(x, 20), fyne.NewPos(x, rhythmStaffHeight-20)
+				cursor.Refresh()
+			case !closing: // the last bar line: taps on the last note may still come, up to a window late
+				closing = true
+				met.Stop()
+				go func() {
+					<-clk.After(window)
+					withGame(finish)
+				}()
+			}
+		})
+	})
+
+	startButton := widget.NewButton("Start", func() {
+		gameMu.Lock()
+		defer gameMu.Unlock()
+		if running {
+			return
+		}
+		shortestValue := []noteValue{quarterNote, eighthNote, sixteenthNote}[shortest.SelectedIndex()]
+		bars = nil
+		for m := 0; m < rhythmMeasures; m++ {
+			bars = append(bars, generateRhythmMeasure(rng, rhythmBeatsPerBar, shortestValue))
+		}
+		cursor = canvas.NewLine(color.RGBA{R: 220, G: 0, B: 0, A: 180})
+		cursor.StrokeWidth = 2
+		staff.Objects = append(append(drawRhythmStaff(bars), cursor), tappable)
+		staff.Refresh()
+		results.Objects = nil
+		results.Refresh()
+
+		met.BPM = bpm.Value
+		beat = time.Duration(float64(time.Minute) / met.BPM)
+		window = beat / 4 // a sixteenth either way
+		if window > 250*time.Millisecond {
+			window = 250 * time.Millisecond
+		}
+		taps = nil
+		running, closing = true, false
+		start = met.Start()
+		expected = make([][]time.Time, len(bars))
+		for m, bar := range bars {
+			for _, onset := range bar.onsets() {
+				at := float64((m+1)*rhythmBeatsPerBar) + onset // +1 bar for the count-in
+				expected[m] = append(expected[m], start.Add(time.Duration(at*float64(beat))))
+			}
+		}
+	})
+	w.SetOnClosed(met.Stop)
+
+	w.SetContent(container.NewVBox(
+		status,
+		container.NewGridWrap(fyne.NewSize(rhythmStaffWidth, rhythmStaffHeight), staff),
+		container.NewHBox(startButton, widget.NewLabel("Tempo"), container.NewGridWrap(fyne.NewSize(200, 40), bpm), bpmLabel, widget.NewLabel("Shortest note"), shortest),
+		results,
+	))
+	w.Resize(fyne.NewSize(rhythmStaffWidth+40, 600))
+	w.Show()
+}
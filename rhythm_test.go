@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Taps go to the measure of their nearest onset: a late tap on a measure's last sixteenth, past where the next bar's
+// window opens, still counts for its own measure, and so does a late tap on the exercise's very last note.
+func TestScoreExerciseLateTaps(t *testing.T) {
+	const beat = 400 * time.Millisecond
+	window := beat / 4
+	at := func(beats float64, late time.Duration) time.Time {
+		return time.Time{}.Add(time.Duration(beats*float64(beat)) + late)
+	}
+	expected := [][]time.Time{
+		{at(0, 0), at(3.75, 0)}, // bar 1 ends on a sixteenth
+		{at(5, 0), at(7.75, 0)}, // bar 2 starts with a rest and ends on a sixteenth
+	}
+	tests := []struct {
+		name     string
+		taps     []time.Time
+		offsets  [][]time.Duration // per measure, the offset of each onset's tap; -1 for a miss
+		extras   []int
+		accuracy []float64
+	}{
+		{
+			name:     "on time",
+			taps:     []time.Time{at(0, 0), at(3.75, 0), at(5, 0), at(7.75, 0)},
+			offsets:  [][]time.Duration{{0, 0}, {0, 0}},
+			extras:   []int{0, 0},
+			accuracy: []float64{1, 1},
+		},
+		{
+			name:     "late on the last notes",
+			taps:     []time.Time{at(0, 0), at(3.75, 90*time.Millisecond), at(5, 0), at(7.75, 90*time.Millisecond)},
+			offsets:  [][]time.Duration{{0, 90 * time.Millisecond}, {0, 90 * time.Millisecond}},
+			extras:   []int{0, 0},
+			accuracy: []float64{0.55, 0.55},
+		},
+		{
+			name:     "too late for the last note",
+			taps:     []time.Time{at(0, 0), at(3.75, 0), at(5, 0), at(7.75, 150*time.Millisecond)},
+			offsets:  [][]time.Duration{{0, 0}, {0, -1}},
+			extras:   []int{0, 1},
+			accuracy: []float64{1, 1.0 / 3},
+		},
+		{
+			name:     "early for the next bar",
+			taps:     []time.Time{at(0, 0), at(3.75, 0), at(5, -80*time.Millisecond), at(7.75, 0)},
+			offsets:  [][]time.Duration{{0, 0}, {-80 * time.Millisecond, 0}},
+			extras:   []int{0, 0},
+			accuracy: []float64{1, 0.6},
+		},
+	}
+	for _, tt := range tests {
+		scored, extras := scoreExercise(expected, tt.taps, window)
+		for m := range expected {
+			for i, r := range scored[m] {
+				want := tt.offsets[m][i]
+				if r.Hit != (want != -1) || r.Hit && r.Offset != want {
+					t.Errorf("%s: bar %d, onset %d: got %+v, want offset %v", tt.name, m+1, i+1, r, want)
+				}
+			}
+			if extras[m] != tt.extras[m] {
+				t.Errorf("%s: bar %d: %d extra taps, want %d", tt.name, m+1, extras[m], tt.extras[m])
+			}
+			if got := measureAccuracy(scored[m], extras[m], window); got < tt.accuracy[m]-1e-9 || got > tt.accuracy[m]+1e-9 {
+				t.Errorf("%s: bar %d: accuracy %.3f, want %.3f", tt.name, m+1, got, tt.accuracy[m])
+			}
+		}
+	}
+}
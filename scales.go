@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Scales, built on the interval model. ::: A scale is a tonic and a pattern of steps; each step is an interval, so the
+// checker knows both which letter comes next and how many semitones away it must be, and never looks at Y distances.
+
+var (
+	halfStep         = interval{1, 1}
+	wholeStep        = interval{1, 2}
+	augmentedSecond  = interval{1, 3} // the step and a half of the harmonic minor
+	pentatonicMinor3 = interval{2, 3} // the gap in a pentatonic scale
+)
+
+// scaleKind is a pattern of steps from the tonic up to its octave.
+type scaleKind int
+
+const (
+	majorScale scaleKind = iota
+	naturalMinorScale
+	harmonicMinorScale
+	melodicMinorScale
+	dorianMode
+	phrygianMode
+	lydianMode
+	mixolydianMode
+	locrianMode
+	majorPentatonicScale
+	minorPentatonicScale
+)
+
+// scaleKinds names each kind of scale and lists its steps.
+var scaleKinds = []struct {
+	Name  string
+	Steps []interval
+}{
+	majorScale:           {"major", stepPattern("WWHWWWH")},
+	naturalMinorScale:    {"natural minor", stepPattern("WHWWHWW")},
+	harmonicMinorScale:   {"harmonic minor", stepPattern("WHWWHAH")},
+	melodicMinorScale:    {"melodic minor (ascending)", stepPattern("WHWWWWH")},
+	dorianMode:           {"Dorian", stepPattern("WHWWWHW")},
+	phrygianMode:         {"Phrygian", stepPattern("HWWWHWW")},
+	lydianMode:           {"Lydian", stepPattern("WWWHWWH")},
+	mixolydianMode:       {"Mixolydian", stepPattern("WWHWWHW")},
+	locrianMode:          {"Locrian", stepPattern("HWWHWWW")},
+	majorPentatonicScale: {"major pentatonic", stepPattern("WWmWm")},
+	minorPentatonicScale: {"minor pentatonic", stepPattern("mWWmW")},
+}
+
+// stepPattern spells steps the way theory books do: W(hole), H(alf), A(ugmented second), m(inor third).
+func stepPattern(pattern string) []interval {
+	steps := map[rune]interval{'W': wholeStep, 'H': halfStep, 'A': augmentedSecond, 'm': pentatonicMinor3}
+	var intervals []interval
+	for _, r := range pattern {
+		intervals = append(intervals, steps[r])
+	}
+	return intervals
+}
+
+// scale is a kind of scale on a tonic, e.g. D4 harmonic minor.
+type scale struct {
+	Tonic Pitch
+	Kind  scaleKind
+}
+
+func (s scale) String() string {
+	return fmt.Sprintf("%s %s", s.Tonic.name(), scaleKinds[s.Kind].Name)
+}
+
+// notes spells the scale from the tonic up to its octave.
+func (s scale) notes() []Pitch {
+	notes := []Pitch{s.Tonic}
+	for _, step := range scaleKinds[s.Kind].Steps {
+		notes = append(notes, notes[len(notes)-1].transpose(step))
+	}
+	return notes
+}
+
+// stepName describes a distance in semitones between neighbouring degrees.
+func stepName(semitones int) string {
+	switch semitones {
+	case 0:
+		return "no step at all"
+	case 1:
+		return "a half step"
+	case 2:
+		return "a whole step"
+	case 3:
+		return "a step and a half"
+	}
+	return fmt.Sprintf("%d half steps", semitones)
+}
+
+// judgeScale checks placed notes (in any order) against the scale, degree by degree from the tonic, and names the
+// first degree that is wrong: a skipped or repeated letter, or a whole step where a half step belongs (or the reverse).
+func judgeScale(s scale, placed []Pitch) (string, bool) {
+	placed = append([]Pitch{}, placed...)
+	sort.Slice(placed, func(i, j int) bool { return placed[i].step() < placed[j].step() })
+	want := s.notes()
+	if len(placed) == 0 || placed[0] != s.Tonic {
+		return fmt.Sprintf("Start %s on its tonic, %s", s, s.Tonic), false
+	}
+	for i, step := range scaleKinds[s.Kind].Steps {
+		degree := i + 2
+		if i+1 >= len(placed) {
+			return fmt.Sprintf("Degree %d is missing: %s comes after %s", degree, want[i+1], placed[i]), false
+		}
+		from, to := placed[i], placed[i+1]
+		if letters := to.step() - from.step(); letters != step.Steps {
+			return fmt.Sprintf("Degree %d: %s should be spelled on %s, the next letter after %s", degree, to, want[i+1].Letter, from.Letter), false
+		}
+		if semis := to.MIDI() - from.MIDI(); semis != step.Semitones {
+			return fmt.Sprintf("Degree %d: from %s to %s is %s, but this scale needs %s (%s)",
+				degree, from, to, stepName(semis), stepName(step.Semitones), want[i+1]), false
+		}
+	}
+	if extra := len(placed) - len(want); extra > 0 {
+		return fmt.Sprintf("The scale ends on %s; %d notes too many", want[len(want)-1], extra), false
+	}
+	return fmt.Sprintf("Perfect! %s", s), true
+}
+
+// randomScale picks a scale whose notes fit the chosen staff and need no double sharps or flats.
+func randomScale(rng *rand.Rand, treble bool) scale {
+	low, top := trebleLowStep, trebleTopStep
+	if !treble {
+		low, top = bassLowStep, trebleLowStep
+	}
+	for {
+		tonic := stackUp([]Pitch{mustParsePitch(chordRootNames[rng.Intn(len(chordRootNames))] + "0")}, low)[0]
+		s := scale{Tonic: tonic, Kind: scaleKind(rng.Intn(len(scaleKinds)))}
+		notes := s.notes()
+		simple := notes[len(notes)-1].step() <= top
+		for _, p := range notes {
+			simple = simple && p.Accidental >= -1 && p.Accidental <= 1
+		}
+		if simple {
+			return s
+		}
+	}
+}
+
+// scaleNoteX is where the next note of an n-note scale goes: the leftmost of the n columns no marked note is in, so a
+// note placed after one was taken back fills the gap instead of landing on top of a later one.
+func scaleNoteX(marks []MarkedNote, n int) float32 {
+	for i := 0; i < n; i++ {
+		x, taken := melodyX(i, n), false
+		for _, m := range marks {
+			taken = taken || m.X == x
+		}
+		if !taken {
+			return x
+		}
+	}
+	return melodyX(len(marks), n) // more notes than the scale has; past its last column
+}
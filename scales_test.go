@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStepPattern(t *testing.T) {
+	got := stepPattern("WHAm")
+	if want := []interval{wholeStep, halfStep, augmentedSecond, pentatonicMinor3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, k := range scaleKinds {
+		steps, semitones := 0, 0
+		for _, iv := range k.Steps {
+			steps, semitones = steps+iv.Steps, semitones+iv.Semitones
+		}
+		if steps != 7 || semitones != 12 {
+			t.Errorf("%s spans %d letters and %d half steps, not an octave", k.Name, steps, semitones)
+		}
+	}
+}
+
+func TestScaleNotes(t *testing.T) {
+	tests := []struct {
+		s    scale
+		want string
+	}{
+		{scale{mustParsePitch("C4"), majorScale}, "C4 D4 E4 F4 G4 A4 B4 C5"},
+		{scale{mustParsePitch("Bb3"), majorScale}, "Bb3 C4 D4 Eb4 F4 G4 A4 Bb4"},
+		{scale{mustParsePitch("D4"), harmonicMinorScale}, "D4 E4 F4 G4 A4 Bb4 C#5 D5"},
+		{scale{mustParsePitch("F#4"), naturalMinorScale}, "F#4 G#4 A4 B4 C#5 D5 E5 F#5"},
+		{scale{mustParsePitch("A3"), minorPentatonicScale}, "A3 C4 D4 E4 G4 A4"},
+		{scale{mustParsePitch("G4"), mixolydianMode}, "G4 A4 B4 C5 D5 E5 F5 G5"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range tt.s.notes() {
+			got = append(got, p.String())
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: got %v, want %s", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestStepName(t *testing.T) {
+	for semitones, want := range map[int]string{0: "no step at all", 1: "a half step", 2: "a whole step", 3: "a step and a half", 4: "4 half steps"} {
+		if got := stepName(semitones); got != want {
+			t.Errorf("stepName(%d) = %q, want %q", semitones, got, want)
+		}
+	}
+}
+
+func TestJudgeScale(t *testing.T) {
+	dMinor := scale{mustParsePitch("D4"), harmonicMinorScale}
+	tests := []struct {
+		name   string
+		placed []Pitch
+		solved bool
+		says   string
+	}{
+		{"right, placed in any order", pitches("C#5", "D4", "F4", "E4", "A4", "G4", "D5", "Bb4"), true, "Perfect!"},
+		{"nothing placed", nil, false, "Start D harmonic minor on its tonic, D4"},
+		{"the wrong tonic", pitches("E4", "F4"), false, "on its tonic"},
+		{"stopped early", pitches("D4", "E4", "F4"), false, "Degree 4 is missing: G4 comes after F4"},
+		{"a skipped letter", pitches("D4", "E4", "F4", "A4", "Bb4", "C#5", "D5"), false, "Degree 4: A4 should be spelled on G"},
+		{"a whole step for a half step", pitches("D4", "E4", "F#4", "G4", "A4", "Bb4", "C#5", "D5"), false, "Degree 3: from E4 to F#4 is a whole step, but this scale needs a half step (F4)"},
+		{"the natural minor's seventh", pitches("D4", "E4", "F4", "G4", "A4", "Bb4", "C5", "D5"), false, "Degree 7: from Bb4 to C5 is a whole step, but this scale needs a step and a half (C#5)"},
+		{"past the octave", pitches("D4", "E4", "F4", "G4", "A4", "Bb4", "C#5", "D5", "E5"), false, "1 notes too many"},
+	}
+	for _, tt := range tests {
+		msg, solved := judgeScale(dMinor, tt.placed)
+		if solved != tt.solved || !strings.Contains(msg, tt.says) {
+			t.Errorf("%s: %v %q, want %v and %q", tt.name, solved, msg, tt.solved, tt.says)
+		}
+	}
+}
+
+// Random scales stay on their staff, ledger notes included, and keep to single sharps and flats.
+func TestRandomScale(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		treble := i%2 == 0
+		low, top := trebleLowStep, trebleTopStep
+		if !treble {
+			low, top = bassLowStep, trebleLowStep
+		}
+		s := randomScale(rng, treble)
+		notes := s.notes()
+		if notes[0].step() < low || notes[len(notes)-1].step() > top {
+			t.Fatalf("%s from %s to %s is off the staff", s, notes[0], notes[len(notes)-1])
+		}
+		for _, p := range notes {
+			if p.Accidental < -1 || p.Accidental > 1 {
+				t.Fatalf("%s has %s", s, p)
+			}
+		}
+	}
+}
+
+// A note placed after one was taken back goes into the gap it left, not on top of the notes after it.
+func TestScaleNoteX(t *testing.T) {
+	const n = 8
+	mark := func(columns ...int) []MarkedNote {
+		var marks []MarkedNote
+		for _, c := range columns {
+			marks = append(marks, MarkedNote{X: melodyX(c, n)})
+		}
+		return marks
+	}
+	tests := []struct {
+		name   string
+		marks  []MarkedNote
+		column int
+	}{
+		{"the first note", nil, 0},
+		{"the next note", mark(0, 1, 2), 3},
+		{"after the second was taken back", mark(0, 2, 3), 1},
+		{"after the first was taken back", mark(1, 2), 0},
+		{"more notes than the scale has", mark(0, 1, 2, 3, 4, 5, 6, 7), 8},
+	}
+	for _, tt := range tests {
+		if got := scaleNoteX(tt.marks, n); got != melodyX(tt.column, n) {
+			t.Errorf("%s: x %g, want column %d at %g", tt.name, got, tt.column, melodyX(tt.column, n))
+		}
+	}
+}
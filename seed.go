@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"math/rand"
+	"time"
+)
+
+// Seeded rounds. ::: Every random choice the game makes comes from one gameRandom, built on a rand.Source the caller
+// hands in, so a sitting replays exactly from its seed: for a bug report (--seed), or for a whole class at once (the
+// Daily Challenge, whose seed is the date). The seed and the round's place in its sequence are logged with each round.
+
+var seedFlag = flag.Int64("seed", 0, "replay the rounds of an earlier sitting from its seed (0 picks a fresh one)")
+
+// gameRandom is the game's random numbers, and the seed and round count that reproduce them.
+type gameRandom struct {
+	*rand.Rand
+	Seed  int64
+	Round int // rounds dealt since the seed was set
+}
+
+// newGameRandom draws from source, which should be seeded with seed; the seed is only kept for the logs.
+func newGameRandom(seed int64, source rand.Source) *gameRandom {
+	return &gameRandom{Rand: rand.New(source), Seed: seed}
+}
+
+// nextRound counts a round dealt, returning its number in the sequence (1 for the first).
+func (g *gameRandom) nextRound() int {
+	g.Round++
+	return g.Round
+}
+
+// sessionSeed is the --seed given, if any, else one from the clock.
+func sessionSeed(flagged int64, now time.Time) int64 {
+	if flagged != 0 {
+		return flagged
+	}
+	return now.UnixNano()
+}
+
+// dailySeed is the same for everyone all day: the local date as a number, e.g. 20261019.
+func dailySeed(day time.Time) int64 {
+	y, m, d := day.Date()
+	return int64(y*10000 + int(m)*100 + d)
+}
+
+// dailyLevel is the level of the Daily Challenge with this seed. The levels take turns from one day to the next, so
+// the whole class gets the same one, and over a week every one of them.
+func dailyLevel(seed int64) int {
+	return int(seed % int64(len(levels)))
+}
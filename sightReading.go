@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The sight-reading mode. ::: A generated melody is drawn across the Grand Staff and the student names (letter buttons,
+// computer keyboard) or plays (MIDI keyboard) each note in turn while a cursor moves along; at the end the speed in
+// notes per minute and the positions of any mistakes are reported.
+
+// sightReadingOptions are the difficulty knobs of the melody generator.
+type sightReadingOptions struct {
+	Low, High        Pitch   // the range, as naturals on the Grand Staff
+	Length           int     // notes per melody
+	LeapChance       float64 // chance that a note leaps (a third up to a sixth) rather than steps from the one before
+	AccidentalChance float64 // chance that a note is sharpened or flattened
+}
+
+var defaultSightReadingOptions = sightReadingOptions{
+	Low: mustParsePitch("C4"), High: mustParsePitch("G5"), Length: 12, LeapChance: 0.25,
+}
+
+// generateMelody writes a melody within the options' range; it turns back at the edges of the range.
+func generateMelody(rng *rand.Rand, o sightReadingOptions) []Pitch {
+	low, high := o.Low.step(), o.High.step()
+	at := low + rng.Intn(high-low+1)
+	var melody []Pitch
+	for i := 0; i < o.Length; i++ {
+		if i > 0 {
+			size := 1
+			if rng.Float64() < o.LeapChance {
+				size = 2 + rng.Intn(4)
+			}
+			if rng.Intn(2) == 0 {
+				size = -size
+			}
+			if at+size < low || at+size > high {
+				size = -size
+			}
+			at += size
+			if at < low {
+				at = low
+			} else if at > high {
+				at = high
+			}
+		}
+		p := naturalAt(at)
+		if rng.Float64() < o.AccidentalChance {
+			p.Accidental = 1
+			if rng.Intn(2) == 0 {
+				p.Accidental = -1
+			}
+			switch p.String()[:2] { // keep to the usual black keys: no E#, B#, Cb or Fb
+			case "E#", "B#", "Cb", "Fb":
+				p.Accidental = -p.Accidental
+			}
+		}
+		melody = append(melody, p)
+	}
+	return melody
+}
+
+// sightReading is one run through a melody.
+type sightReading struct {
+	Melody  []Pitch
+	Cursor  int       // the note being read
+	Started time.Time // when the melody was shown
+	Ended   time.Time // when the last note was answered
+	Errors  []int     // the index of the note for each wrong answer
+}
+
+func newSightReading(melody []Pitch, now time.Time) *sightReading {
+	return &sightReading{Melody: melody, Started: now}
+}
+
+// done reports whether every note has been answered.
+func (s *sightReading) done() bool { return s.Cursor >= len(s.Melody) }
+
+// answer checks p against the note under the cursor and moves on when it's right. A named letter only has to match
+// the letter (the buttons have no accidentals); a played note has to be the same key, so Gb4 is also F#4.
+func (s *sightReading) answer(p Pitch, letterOnly bool, now time.Time) bool {
+	if s.done() {
+		return false
+	}
+	want := s.Melody[s.Cursor]
+	right := p.MIDI() == want.MIDI()
+	if letterOnly {
+		right = p.Letter == want.Letter
+	}
+	if !right {
+		s.Errors = append(s.Errors, s.Cursor)
+		return false
+	}
+	s.Cursor++
+	if s.done() {
+		s.Ended = now
+	}
+	return true
+}
+
+// notesPerMinute is the reading speed over the whole melody, mistakes and all.
+func (s *sightReading) notesPerMinute() float64 {
+	elapsed := s.Ended.Sub(s.Started)
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(len(s.Melody)) / elapsed.Minutes()
+}
+
+// report sums up a finished run.
+func (s *sightReading) report() string {
+	msg := fmt.Sprintf("%d notes in %.1fs: %.1f notes/min", len(s.Melody), s.Ended.Sub(s.Started).Seconds(), s.notesPerMinute())
+	if len(s.Errors) == 0 {
+		return msg + ", no mistakes!"
+	}
+	var at []string
+	for _, i := range s.Errors {
+		at = append(at, fmt.Sprintf("%d (%s)", i+1, s.Melody[i]))
+	}
+	return fmt.Sprintf("%s, %d mistakes at notes %s", msg, len(s.Errors), strings.Join(at, ", "))
+}
+
+// melodyX spreads n notes evenly across the Grand Staff.
+func melodyX(i, n int) float32 {
+	left, right := float32(grandStaffLeft+clefWidth+30), float32(grandStaffRight-30)
+	if n < 2 {
+		return left
+	}
+	return left + float32(i)*(right-left)/float32(n-1)
+}
+
+// drawMelody draws the melody on the Grand Staff, an accidental sign to the left of each altered note.
+func drawMelody(notePositions []NotePosition, melody []Pitch, c color.Color) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	for i, p := range melody {
+		pos, ok := findNotePosition(notePositions, p.natural().String())
+		if !ok {
+			continue
+		}
+		x := melodyX(i, len(melody))
+		objects = append(objects, drawStaffNote(pos, x, c)...)
+		if p.Accidental != 0 {
+			objects = append(objects, drawAccidental(p.Accidental, x, pos.Y, grandStaffMetrics, c))
+		}
+	}
+	return objects
+}
+
+// showSightReadingDialog sets the difficulty knobs; staffPitches are the notes of the Grand Staff, top to bottom.
+func showSightReadingDialog(parentWindow fyne.Window, current sightReadingOptions, staffPitches []string, onStart func(sightReadingOptions)) {
+	low := widget.NewSelect(staffPitches, nil)
+	low.SetSelected(current.Low.String())
+	high := widget.NewSelect(staffPitches, nil)
+	high.SetSelected(current.High.String())
+	length := widget.NewSelect([]string{"8", "12", "16", "24"}, nil)
+	length.SetSelected(strconv.Itoa(current.Length))
+	leaps := widget.NewSlider(0, 1)
+	leaps.Step = 0.05
+	leaps.SetValue(current.LeapChance)
+	accidentals := widget.NewSlider(0, 1)
+	accidentals.Step = 0.05
+	accidentals.SetValue(current.AccidentalChance)
+
+	dialog.ShowForm("Sight Reading", "Start", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("Lowest note", low),
+			widget.NewFormItem("Highest note", high),
+			widget.NewFormItem("Notes", length),
+			widget.NewFormItem("Leaps (vs. steps)", leaps),
+			widget.NewFormItem("Accidentals", accidentals),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			o := sightReadingOptions{Low: mustParsePitch(low.Selected), High: mustParsePitch(high.Selected),
+				LeapChance: leaps.Value, AccidentalChance: accidentals.Value}
+			o.Length, _ = strconv.Atoi(length.Selected) // the choices are all numbers
+			if o.Low.step() > o.High.step() {
+				o.Low, o.High = o.High, o.Low
+			}
+			withGame(func() { onStart(o) })
+		}, parentWindow)
+}
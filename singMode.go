@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// The "sing or play the highlighted note" mode. ::: Answers arrive as recordings: the student records themselves to a
+// WAV file, and the pitch detector decides what was heard. A MIDI keyboard can answer these rounds too.
+
+// judgeSung compares what was heard with the highlighted note; singers often land in the right place an octave off.
+func judgeSung(target, heard Pitch) (string, bool) {
+	switch {
+	case heard.MIDI() == target.MIDI():
+		return fmt.Sprintf("Perfect! That was %s", target), true
+	case (heard.MIDI()-target.MIDI())%12 == 0:
+		return fmt.Sprintf("Right note, wrong octave: heard %s, wanted %s", heard, target), false
+	case heard.MIDI() < target.MIDI():
+		return fmt.Sprintf("Too low: heard %s, wanted %s", heard, target), false
+	default:
+		return fmt.Sprintf("Too high: heard %s, wanted %s", heard, target), false
+	}
+}
+
+// showWAVAnswerDialog lets the student pick a recording and reports the first steady pitch found in it.
+func showWAVAnswerDialog(parentWindow fyne.Window, onHeard func(pitchReading)) {
+	open := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+		wav, err := newWAVReader(reader)
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		reading, err := listenForPitch(wav)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("%s: %w", reader.URI().Name(), err), parentWindow)
+			return
+		}
+		fmt.Printf("Heard %s (%.1f Hz, %+.0f cents, confidence %.2f)\n", reading.Pitch, reading.Freq, reading.Cents, reading.Confidence)
+		withGame(func() { onHeard(reading) })
+	}, parentWindow)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{".wav"}))
+	open.Show()
+}
+
+// showToneSaveDialog saves two seconds of the pitch as a WAV file, to sing along with or to check the detector against.
+func showToneSaveDialog(parentWindow fyne.Window, p Pitch) {
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+		if err := writeWAV(writer, synthesizeTone(frequencyOf(p), 2, synthSampleRate), synthSampleRate); err != nil {
+			dialog.ShowError(err, parentWindow)
+		}
+	}, parentWindow)
+	save.SetFileName(p.String() + ".wav")
+	save.Show()
+}
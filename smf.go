@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A small Standard MIDI File (SMF) writer. ::: Type 0 puts everything on one track, Type 1 gives each part its own track.
+// Events are built with absolute tick times and only converted to delta-times when a track is written out.
+
+const (
+	smfTicksPerQuarter = 480 // division: pulses per quarter note, a common DAW default
+	smfDefaultVelocity = 90
+)
+
+// smfEvent is a single MIDI or meta event placed at an absolute tick.
+type smfEvent struct {
+	Tick uint32
+	Data []byte // status byte and data bytes; a meta event starts with 0xFF
+}
+
+// smfTrack is one MTrk chunk: a name plus its events (in any order; they are sorted by tick when written).
+type smfTrack struct {
+	Name   string
+	Events []smfEvent
+}
+
+// addNote appends a note-on and its matching note-off; length is in ticks.
+func (t *smfTrack) addNote(channel int, pitch Pitch, start, length uint32, velocity byte) {
+	key := byte(pitch.MIDI() & 0x7F)
+	ch := byte(channel & 0x0F)
+	t.Events = append(t.Events,
+		smfEvent{Tick: start, Data: []byte{0x90 | ch, key, velocity}},
+		smfEvent{Tick: start + length, Data: []byte{0x80 | ch, key, 0}},
+	)
+}
+
+// addTempo appends a Set Tempo meta event (FF 51 03 tttttt), expressed in microseconds per quarter note.
+func (t *smfTrack) addTempo(tick uint32, bpm int) {
+	if bpm <= 0 {
+		bpm = 120
+	}
+	usPerQuarter := uint32(60000000 / bpm)
+	t.Events = append(t.Events, smfEvent{Tick: tick, Data: []byte{0xFF, 0x51, 0x03,
+		byte(usPerQuarter >> 16), byte(usPerQuarter >> 8), byte(usPerQuarter)}})
+}
+
+// addText appends a text-type meta event, e.g., 0x01 text, 0x05 lyric, 0x06 marker.
+func (t *smfTrack) addText(tick uint32, kind byte, text string) {
+	data := append([]byte{0xFF, kind}, encodeVLQ(uint32(len(text)))...)
+	t.Events = append(t.Events, smfEvent{Tick: tick, Data: append(data, text...)})
+}
+
+// writeSMF writes a complete Standard MIDI File to w. format must be 0 or 1; for format 0 the tracks are merged into one.
+func writeSMF(w io.Writer, format int, tracks []smfTrack) error {
+	if format != 0 && format != 1 {
+		return fmt.Errorf("unsupported SMF format %d", format)
+	}
+	if len(tracks) == 0 {
+		return fmt.Errorf("no tracks to write")
+	}
+	if format == 0 && len(tracks) > 1 {
+		tracks = []smfTrack{mergeSMFTracks(tracks)}
+	}
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 14) // ::: MThd, length 6, format, ntrks, division
+	copy(header, "MThd")
+	binary.BigEndian.PutUint32(header[4:], 6)
+	binary.BigEndian.PutUint16(header[8:], uint16(format))
+	binary.BigEndian.PutUint16(header[10:], uint16(len(tracks)))
+	binary.BigEndian.PutUint16(header[12:], smfTicksPerQuarter)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	for _, track := range tracks {
+		chunk := encodeSMFTrack(track)
+		var chunkHeader [8]byte
+		copy(chunkHeader[:], "MTrk")
+		binary.BigEndian.PutUint32(chunkHeader[4:], uint32(len(chunk)))
+		if _, err := bw.Write(chunkHeader[:]); err != nil {
+			return err
+		}
+		if _, err := bw.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// encodeSMFTrack turns a track into the body of an MTrk chunk: track name first, delta-timed events, then End of Track.
+func encodeSMFTrack(track smfTrack) []byte {
+	events := make([]smfEvent, len(track.Events))
+	copy(events, track.Events)
+	sortSMFEvents(events)
+
+	var body []byte
+	if track.Name != "" {
+		body = append(body, 0x00, 0xFF, 0x03) // delta 0, Sequence/Track Name
+		body = append(body, encodeVLQ(uint32(len(track.Name)))...)
+		body = append(body, track.Name...)
+	}
+	var last uint32
+	for _, e := range events {
+		body = append(body, encodeVLQ(e.Tick-last)...)
+		body = append(body, e.Data...)
+		last = e.Tick
+	}
+	return append(body, 0x00, 0xFF, 0x2F, 0x00) // End of Track
+}
+
+// sortSMFEvents puts events in playing order; at the same tick, old notes are released before new ones are struck.
+func sortSMFEvents(events []smfEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Tick != events[j].Tick {
+			return events[i].Tick < events[j].Tick
+		}
+		return isNoteOff(events[i].Data) && !isNoteOff(events[j].Data)
+	})
+}
+
+// mergeSMFTracks folds several tracks into one for a Type 0 file; the first track's name is kept.
+func mergeSMFTracks(tracks []smfTrack) smfTrack {
+	merged := smfTrack{Name: tracks[0].Name}
+	for i, t := range tracks {
+		if i > 0 && t.Name != "" {
+			merged.addText(0, 0x01, t.Name) // keep the part names around as plain text events
+		}
+		merged.Events = append(merged.Events, t.Events...)
+	}
+	return merged
+}
+
+// isNoteOff reports whether a raw event releases a note (0x8n, or 0x9n with velocity 0).
+func isNoteOff(data []byte) bool {
+	if len(data) < 3 {
+		return false
+	}
+	status := data[0] & 0xF0
+	return status == 0x80 || (status == 0x90 && data[2] == 0)
+}
+
+// encodeVLQ encodes n as a MIDI variable-length quantity: 7 bits per byte, high bit set on all but the last byte.
+func encodeVLQ(n uint32) []byte {
+	out := []byte{byte(n & 0x7F)}
+	for n >>= 7; n > 0; n >>= 7 {
+		out = append([]byte{byte(n&0x7F) | 0x80}, out...)
+	}
+	return out
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// The reading half of the SMF support: it parses Type 0, 1 and 2 files back into the smfTrack/smfEvent model used by the writer.
+// ::: Running status is expanded as the file is read, so every stored channel event carries its own status byte.
+
+// smfFile is a parsed Standard MIDI File.
+type smfFile struct {
+	Format   int
+	Division uint16 // ticks per quarter note (or an SMPTE code when the high bit is set)
+	Tracks   []smfTrack
+}
+
+// readSMF parses a Standard MIDI File. Unknown chunk types are skipped, as the spec asks.
+func readSMF(r io.Reader) (*smfFile, error) {
+	br := bufio.NewReader(r)
+	id, body, err := readSMFChunk(br)
+	if err != nil {
+		return nil, fmt.Errorf("reading MIDI header: %w", err)
+	}
+	if id != "MThd" || len(body) < 6 {
+		return nil, fmt.Errorf("not a Standard MIDI File (found %q)", id)
+	}
+	f := &smfFile{
+		Format:   int(binary.BigEndian.Uint16(body[0:])),
+		Division: binary.BigEndian.Uint16(body[4:]),
+	}
+	trackCount := int(binary.BigEndian.Uint16(body[2:]))
+
+	for len(f.Tracks) < trackCount {
+		id, body, err := readSMFChunk(br)
+		if err == io.EOF {
+			break // some files overstate ntrks; keep what we have
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading track %d: %w", len(f.Tracks)+1, err)
+		}
+		if id != "MTrk" {
+			continue
+		}
+		track, err := parseSMFTrack(body)
+		if err != nil {
+			return nil, fmt.Errorf("parsing track %d: %w", len(f.Tracks)+1, err)
+		}
+		f.Tracks = append(f.Tracks, track)
+	}
+	if len(f.Tracks) == 0 {
+		return nil, fmt.Errorf("the MIDI file has no tracks")
+	}
+	return f, nil
+}
+
+// readSMFChunk reads one chunk: a four-character id, a 32-bit length, and that many bytes. The length is only trusted
+// as far as the file goes: the body is read through a LimitReader, so a corrupt length can't allocate gigabytes.
+func readSMFChunk(r io.Reader) (string, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return "", nil, err
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	body, err := io.ReadAll(io.LimitReader(r, int64(length)))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(body) < int(length) {
+		return "", nil, fmt.Errorf("%q chunk of %d bytes is cut short at %d: %w", header[:4], length, len(body), io.ErrUnexpectedEOF)
+	}
+	return string(header[:4]), body, nil
+}
+
+// parseSMFTrack decodes the events of an MTrk chunk into absolute-tick smfEvents. Sysex events are skipped.
+func parseSMFTrack(data []byte) (smfTrack, error) {
+	var track smfTrack
+	var tick uint32
+	var running byte // the last channel status byte, for running status
+	pos := 0
+	for pos < len(data) {
+		delta, n, err := decodeVLQ(data[pos:])
+		if err != nil {
+			return track, err
+		}
+		pos += n
+		tick += delta
+		if pos >= len(data) {
+			return track, fmt.Errorf("event truncated at byte %d", pos)
+		}
+
+		status := data[pos]
+		switch {
+		case status == 0xFF: // ::: meta event: FF type length data
+			if pos+2 > len(data) {
+				return track, fmt.Errorf("meta event truncated at byte %d", pos)
+			}
+			kind := data[pos+1]
+			length, n, err := decodeVLQ(data[pos+2:])
+			if err != nil {
+				return track, err
+			}
+			end := pos + 2 + n + int(length)
+			if end > len(data) {
+				return track, fmt.Errorf("meta event overruns the track at byte %d", pos)
+			}
+			if kind == 0x03 && track.Name == "" {
+				track.Name = string(data[pos+2+n : end])
+			} else if kind == 0x2F {
+				return track, nil // End of Track
+			}
+			track.Events = append(track.Events, smfEvent{Tick: tick, Data: append([]byte(nil), data[pos:end]...)})
+			pos = end
+			running = 0
+		case status == 0xF0 || status == 0xF7: // sysex: F0 length data, skipped
+			length, n, err := decodeVLQ(data[pos+1:])
+			if err != nil {
+				return track, err
+			}
+			pos += 1 + n + int(length)
+			running = 0
+		default: // ::: channel event, possibly using running status
+			if status >= 0x80 {
+				running = status
+				pos++
+			} else if running == 0 {
+				return track, fmt.Errorf("data byte 0x%02X with no running status at byte %d", status, pos)
+			}
+			size := channelEventDataSize(running)
+			if pos+size > len(data) {
+				return track, fmt.Errorf("channel event truncated at byte %d", pos)
+			}
+			event := append([]byte{running}, data[pos:pos+size]...)
+			track.Events = append(track.Events, smfEvent{Tick: tick, Data: event})
+			pos += size
+		}
+	}
+	return track, nil // a missing End of Track is tolerated
+}
+
+// channelEventDataSize returns how many data bytes follow a channel status byte.
+func channelEventDataSize(status byte) int {
+	switch status & 0xF0 {
+	case 0xC0, 0xD0: // program change, channel pressure
+		return 1
+	default:
+		return 2
+	}
+}
+
+// decodeVLQ reads a variable-length quantity, returning the value and how many bytes it used.
+func decodeVLQ(data []byte) (uint32, int, error) {
+	var value uint32
+	for i := 0; i < len(data) && i < 4; i++ {
+		value = value<<7 | uint32(data[i]&0x7F)
+		if data[i]&0x80 == 0 {
+			return value, i + 1, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("bad variable-length quantity")
+}
+
+// smfNote is a sounding note pulled out of a parsed file.
+type smfNote struct {
+	Tick    uint32
+	Channel int
+	Pitch   Pitch
+}
+
+// notes returns every note-on in the file, across all tracks, in time order. Channel 10 (drums) is left out.
+func (f *smfFile) notes() []smfNote {
+	var notes []smfNote
+	for _, track := range f.Tracks {
+		for _, e := range track.Events {
+			if len(e.Data) == 3 && e.Data[0]&0xF0 == 0x90 && e.Data[2] > 0 {
+				channel := int(e.Data[0] & 0x0F)
+				if channel == 9 {
+					continue
+				}
+				notes = append(notes, smfNote{Tick: e.Tick, Channel: channel, Pitch: pitchFromMIDI(int(e.Data[1]))})
+			}
+		}
+	}
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Tick < notes[j].Tick })
+	return notes
+}
+
+// melody reduces the file to a single line by keeping the highest note that starts at each tick (the "skyline").
+func (f *smfFile) melody() []Pitch {
+	var line []Pitch
+	var lastTick uint32
+	for _, n := range f.notes() {
+		if len(line) > 0 && n.Tick == lastTick {
+			if n.Pitch.MIDI() > line[len(line)-1].MIDI() {
+				line[len(line)-1] = n.Pitch
+			}
+			continue
+		}
+		line = append(line, n.Pitch)
+		lastTick = n.Tick
+	}
+	return line
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// The variable-length quantities from the examples in the Standard MIDI File spec.
+func TestVLQ(t *testing.T) {
+	tests := []struct {
+		value   uint32
+		encoded []byte
+	}{
+		{0x00000000, []byte{0x00}},
+		{0x00000040, []byte{0x40}},
+		{0x0000007F, []byte{0x7F}},
+		{0x00000080, []byte{0x81, 0x00}},
+		{0x00002000, []byte{0xC0, 0x00}},
+		{0x00003FFF, []byte{0xFF, 0x7F}},
+		{0x00004000, []byte{0x81, 0x80, 0x00}},
+		{0x00100000, []byte{0xC0, 0x80, 0x00}},
+		{0x001FFFFF, []byte{0xFF, 0xFF, 0x7F}},
+		{0x00200000, []byte{0x81, 0x80, 0x80, 0x00}},
+		{0x08000000, []byte{0xC0, 0x80, 0x80, 0x00}},
+		{0x0FFFFFFF, []byte{0xFF, 0xFF, 0xFF, 0x7F}},
+	}
+	for _, tt := range tests {
+		if got := encodeVLQ(tt.value); !bytes.Equal(got, tt.encoded) {
+			t.Errorf("encodeVLQ(%#x) = % X, want % X", tt.value, got, tt.encoded)
+		}
+		// Whatever follows the quantity is left alone.
+		value, n, err := decodeVLQ(append(append([]byte(nil), tt.encoded...), 0x90, 0x3C))
+		if err != nil || value != tt.value || n != len(tt.encoded) {
+			t.Errorf("decodeVLQ(% X) = %#x, %d, %v; want %#x, %d, nil", tt.encoded, value, n, err, tt.value, len(tt.encoded))
+		}
+	}
+}
+
+func TestDecodeVLQErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":            nil,
+		"unterminated":     {0x81, 0x80},
+		"longer than four": {0x81, 0x80, 0x80, 0x80, 0x00},
+	}
+	for name, data := range tests {
+		if _, _, err := decodeVLQ(data); err == nil {
+			t.Errorf("%s: decodeVLQ(% X) gave no error", name, data)
+		}
+	}
+}
+
+// channelEvents are a track's non-meta events, in the order they are written.
+func channelEvents(track smfTrack) []smfEvent {
+	events := append([]smfEvent(nil), track.Events...)
+	sortSMFEvents(events)
+	var out []smfEvent
+	for _, e := range events {
+		if e.Data[0] != 0xFF {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func TestSMFRoundTrip(t *testing.T) {
+	melody := smfTrack{Name: "Melody"}
+	melody.addTempo(0, 100)
+	for i, name := range []string{"C4", "E4", "G4", "C5"} {
+		melody.addNote(0, mustParsePitch(name), uint32(i)*smfTicksPerQuarter, smfTicksPerQuarter, smfDefaultVelocity)
+	}
+	bass := smfTrack{Name: "Bass"}
+	bass.addNote(1, mustParsePitch("C3"), 0, 4*smfTicksPerQuarter, 70)
+	bass.addNote(1, mustParsePitch("F#2"), 4*smfTicksPerQuarter, 200000, 70) // a delta that needs three VLQ bytes
+
+	tests := []struct {
+		name   string
+		format int
+		tracks []smfTrack
+		want   []smfTrack // what should come back, ignoring meta events
+	}{
+		{"type 0, one track", 0, []smfTrack{melody}, []smfTrack{melody}},
+		{"type 1, two tracks", 1, []smfTrack{melody, bass}, []smfTrack{melody, bass}},
+		{"type 0, merged", 0, []smfTrack{melody, bass}, []smfTrack{mergeSMFTracks([]smfTrack{melody, bass})}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeSMF(&buf, tt.format, tt.tracks); err != nil {
+			t.Fatalf("%s: writeSMF: %v", tt.name, err)
+		}
+		f, err := readSMF(&buf)
+		if err != nil {
+			t.Fatalf("%s: readSMF: %v", tt.name, err)
+		}
+		if f.Format != tt.format || f.Division != smfTicksPerQuarter || len(f.Tracks) != len(tt.want) {
+			t.Fatalf("%s: read format %d, division %d, %d tracks; want %d, %d, %d",
+				tt.name, f.Format, f.Division, len(f.Tracks), tt.format, smfTicksPerQuarter, len(tt.want))
+		}
+		for i, want := range tt.want {
+			if f.Tracks[i].Name != want.Name {
+				t.Errorf("%s: track %d is named %q, want %q", tt.name, i, f.Tracks[i].Name, want.Name)
+			}
+			if got, want := channelEvents(f.Tracks[i]), channelEvents(want); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: track %d events\n got %v\nwant %v", tt.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestSMFRoundTripKeepsTempo(t *testing.T) {
+	track := smfTrack{}
+	track.addTempo(0, 100) // 600000 microseconds per quarter
+	var buf bytes.Buffer
+	if err := writeSMF(&buf, 0, []smfTrack{track}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := readSMF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0xFF, 0x51, 0x03, 0x09, 0x27, 0xC0}
+	if events := f.Tracks[0].Events; len(events) != 1 || !bytes.Equal(events[0].Data, want) {
+		t.Errorf("read back %v, want one tempo event % X", events, want)
+	}
+}
+
+// A hand-made track: running status across note-ons and a program change, a meta event that cancels it, and
+// a sysex dump to skip.
+func TestParseSMFTrackRunningStatus(t *testing.T) {
+	data := []byte{
+		0x00, 0x90, 0x3C, 0x40, // C4 on
+		0x60, 0x3C, 0x00, // running status: C4 off, as a note-on with velocity 0
+		0x00, 0x40, 0x50, // running status: E4 on
+		0x00, 0xC1, 0x05, // program change: one data byte
+		0x10, 0x07, // running status on the program change
+		0x00, 0xF0, 0x03, 0x7E, 0x7F, 0xF7, // sysex, skipped
+		0x81, 0x00, 0x80, 0x40, 0x00, // delta 128: E4 off
+		0x00, 0xFF, 0x2F, 0x00, // End of Track
+		0x00, 0x90, 0x48, 0x40, // after the end: ignored
+	}
+	track, err := parseSMFTrack(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []smfEvent{
+		{Tick: 0, Data: []byte{0x90, 0x3C, 0x40}},
+		{Tick: 0x60, Data: []byte{0x90, 0x3C, 0x00}},
+		{Tick: 0x60, Data: []byte{0x90, 0x40, 0x50}},
+		{Tick: 0x60, Data: []byte{0xC1, 0x05}},
+		{Tick: 0x70, Data: []byte{0xC1, 0x07}},
+		{Tick: 0xF0, Data: []byte{0x80, 0x40, 0x00}},
+	}
+	if !reflect.DeepEqual(track.Events, want) {
+		t.Errorf("events\n got %v\nwant %v", track.Events, want)
+	}
+}
+
+func TestParseSMFTrackErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"data byte with no status":  {0x00, 0x3C, 0x40},
+		"running status after meta": {0x00, 0x90, 0x3C, 0x40, 0x00, 0xFF, 0x01, 0x00, 0x00, 0x3C, 0x00},
+		"truncated note":            {0x00, 0x90, 0x3C},
+		"meta overruns the track":   {0x00, 0xFF, 0x01, 0x05, 'a'},
+		"delta with no event":       {0x81},
+	}
+	for name, data := range tests {
+		if _, err := parseSMFTrack(data); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+// A chunk length that runs past the end of the file is an error, not a 4 GB allocation; the same goes for the length
+// of a WAV file's format chunk.
+func TestChunkLengthsBeyondTheFile(t *testing.T) {
+	smf := append([]byte("MThd\xff\xff\xff\xf0"), 0, 1, 0, 1, 0, 96)
+	if _, err := readSMF(bytes.NewReader(smf)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("SMF: err = %v", err)
+	}
+	wav := append([]byte("RIFF\x24\x00\x00\x00WAVEfmt \xff\xff\xff\xf0"), make([]byte, 16)...)
+	if _, err := newWAVReader(bytes.NewReader(wav)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("WAV: err = %v", err)
+	}
+}
+
+// An imported note keeps its accidental on the staff.
+func TestExerciseStaffPitch(t *testing.T) {
+	for _, p := range []string{"F#4", "Bb3", "C5"} {
+		if got := (exerciseRound{Pitch: mustParsePitch(p)}).staffPitch(); got != p {
+			t.Errorf("%s sits on the staff as %s", p, got)
+		}
+	}
+}
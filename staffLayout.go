@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Measures, barlines and time signatures. ::: A measureLayout divides the width of a staff into bars, and each bar into
+// one slot per counted beat; notes placed on the staff snap into those slots from left to right.
+
+// timeSignature is a meter such as 4/4 or 6/8.
+type timeSignature struct {
+	Beats int       // the top number
+	Unit  noteValue // the bottom number: the value that gets one count
+}
+
+func (ts timeSignature) String() string { return fmt.Sprintf("%d/%d", ts.Beats, ts.Unit) }
+
+// commonTimeSignatures are the meters offered in the settings.
+var commonTimeSignatures = []timeSignature{
+	{2, quarterNote}, {3, quarterNote}, {4, quarterNote}, {2, halfNote}, {3, eighthNote}, {6, eighthNote}, {9, eighthNote}, {12, eighthNote},
+}
+
+// measureLayout divides [Left, Right] into Measures bars of Time. With zero measures the staff is "free": one column of notes.
+type measureLayout struct {
+	Measures int
+	Time     timeSignature
+	Left     float32 // where the first bar starts, after the clef and the time signature
+	Right    float32 // the final barline
+}
+
+// grandStaffLeft and grandStaffRight are where the Grand Staff's lines start and end; see main.
+const (
+	grandStaffLeft  = 100
+	grandStaffRight = 900
+	clefWidth       = 50 // left room for a clef at the start of each staff
+	timeSigWidth    = 60
+)
+
+// newGrandStaffLayout lays measures out on the Grand Staff, leaving room for the clef and the time signature.
+func newGrandStaffLayout(measures int, ts timeSignature) measureLayout {
+	return measureLayout{Measures: measures, Time: ts, Left: grandStaffLeft + clefWidth + timeSigWidth, Right: grandStaffRight}
+}
+
+// free reports whether the staff is not divided into measures.
+func (l measureLayout) free() bool { return l.Measures <= 0 }
+
+// slotsPerBar is how many placement slots a bar has: one per counted beat.
+func (l measureLayout) slotsPerBar() int { return l.Time.Beats }
+
+// slotCount is the number of slots across all the bars.
+func (l measureLayout) slotCount() int { return l.Measures * l.slotsPerBar() }
+
+// barWidth is the width of one bar in pixels.
+func (l measureLayout) barWidth() float32 { return (l.Right - l.Left) / float32(l.Measures) }
+
+// barlineXs returns the x-coordinates of the barlines, including the final one (but not the start of the first bar).
+func (l measureLayout) barlineXs() []float32 {
+	var xs []float32
+	for m := 1; m <= l.Measures; m++ {
+		xs = append(xs, l.Left+float32(m)*l.barWidth())
+	}
+	return xs
+}
+
+// slotX is the x-coordinate of a slot, counting across bars from 0; slots sit in the middle of their share of the bar.
+func (l measureLayout) slotX(slot int) float32 {
+	measure, inBar := slot/l.slotsPerBar(), slot%l.slotsPerBar()
+	slotWidth := l.barWidth() / float32(l.slotsPerBar())
+	return l.Left + float32(measure)*l.barWidth() + (float32(inBar)+0.5)*slotWidth
+}
+
+// snap returns the slot nearest to x.
+func (l measureLayout) snap(x float32) int {
+	slotWidth := l.barWidth() / float32(l.slotsPerBar())
+	slot := int((x - l.Left) / slotWidth)
+	if slot < 0 {
+		return 0
+	}
+	if slot >= l.slotCount() {
+		return l.slotCount() - 1
+	}
+	return slot
+}
+
+// drawMeasures draws the barlines through each staff (given as top and bottom line Y, the treble staff first), and the
+// clef and time signature at the start of each one. A free layout draws nothing.
+func drawMeasures(l measureLayout, staffs [][2]float32) []fyne.CanvasObject {
+	if l.free() {
+		return nil
+	}
+	var objects []fyne.CanvasObject
+	for i, x := range l.barlineXs() {
+		width := float32(2)
+		if i == len(l.barlineXs())-1 {
+			width = 5 // the final barline is heavier
+		}
+		for _, staff := range staffs {
+			objects = append(objects, newStroke(color.Black, x, staff[0], x, staff[1], width))
+		}
+	}
+	for i, staff := range staffs {
+		objects = append(objects, drawClef(i == 0, grandStaffLeft+clefWidth/2, staff[0], staff[1]))
+		objects = append(objects, drawTimeSignature(l.Time, grandStaffLeft+clefWidth+timeSigWidth/2, staff[0], staff[1])...)
+	}
+	return objects
+}
+
+// drawClef draws a staff's clef centered on x, as its letter, the way the guitar staff does: a G on the treble staff's
+// second line from the bottom, where the treble clef curls around G4, or an F on the bass staff's second line from the
+// top, the F3 between the bass clef's dots.
+func drawClef(treble bool, x, top, bottom float32) fyne.CanvasObject {
+	gap := (bottom - top) / 4
+	letter, y := "F", top+gap
+	if treble {
+		letter, y = "G", bottom-gap
+	}
+	clef := canvas.NewText(letter, color.Black)
+	clef.TextSize = clefWidth * 1.3
+	clef.TextStyle = fyne.TextStyle{Bold: true}
+	size := clef.MinSize()
+	clef.Resize(size)
+	clef.Move(fyne.NewPos(x-size.Width/2, y-size.Height/2))
+	return clef
+}
+
+// drawTimeSignature stacks the two numbers of a meter between a staff's top and bottom lines, centered on x.
+func drawTimeSignature(ts timeSignature, x, top, bottom float32) []fyne.CanvasObject {
+	half := (bottom - top) / 2
+	var objects []fyne.CanvasObject
+	for i, n := range []int{ts.Beats, int(ts.Unit)} {
+		text := canvas.NewText(strconv.Itoa(n), color.Black)
+		text.TextSize = half * 0.95
+		text.TextStyle = fyne.TextStyle{Bold: true}
+		size := text.MinSize()
+		text.Resize(size)
+		text.Move(fyne.NewPos(x-size.Width/2, top+float32(i)*half+(half-size.Height)/2))
+		objects = append(objects, text)
+	}
+	return objects
+}
+
+// showMeasuresDialog picks how many measures the Grand Staff is divided into, and in what meter.
+func showMeasuresDialog(parentWindow fyne.Window, current measureLayout, onApply func(measureLayout)) {
+	counts := []string{"None (one column)", "1", "2", "3", "4"}
+	count := widget.NewSelect(counts, nil)
+	count.SetSelectedIndex(current.Measures)
+
+	var names []string
+	for _, ts := range commonTimeSignatures {
+		names = append(names, ts.String())
+	}
+	meter := widget.NewSelect(names, nil)
+	meter.SetSelected(current.Time.String())
+	if meter.SelectedIndex() < 0 {
+		meter.SetSelectedIndex(2) // 4/4
+	}
+
+	dialog.ShowForm("Measures", "Apply", "Cancel",
+		[]*widget.FormItem{widget.NewFormItem("Measures", count), widget.NewFormItem("Time signature", meter)},
+		func(ok bool) {
+			if ok {
+				withGame(func() {
+					onApply(newGrandStaffLayout(count.SelectedIndex(), commonTimeSignatures[meter.SelectedIndex()]))
+				})
+			}
+		}, parentWindow)
+}
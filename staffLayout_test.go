@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+)
+
+// With measures, each staff starts with its clef, in the room left for it before the time signature.
+func TestDrawMeasuresClefs(t *testing.T) {
+	test.NewTempApp(t) // the clefs and time signatures are text, measured against the app's theme
+	staffs := [][2]float32{{100, 340}, {520, 760}}
+	if objects := drawMeasures(newGrandStaffLayout(0, commonTimeSignatures[2]), staffs); objects != nil {
+		t.Errorf("the free staff drew %d objects", len(objects))
+	}
+	clefs := map[string][2]float32{} // letter: top and bottom of its text
+	for _, obj := range drawMeasures(newGrandStaffLayout(2, commonTimeSignatures[2]), staffs) {
+		text, ok := obj.(*canvas.Text)
+		if !ok || (text.Text != "G" && text.Text != "F") {
+			continue
+		}
+		if left, right := text.Position().X, text.Position().X+text.Size().Width; left < grandStaffLeft || right > grandStaffLeft+clefWidth {
+			t.Errorf("the %s clef runs from x %g to %g, outside its room", text.Text, left, right)
+		}
+		clefs[text.Text] = [2]float32{text.Position().Y, text.Position().Y + text.Size().Height}
+	}
+	for letter, line := range map[string]float32{"G": 280, "F": 580} { // G4 and F3
+		span, ok := clefs[letter]
+		if !ok {
+			t.Errorf("no %s clef", letter)
+		} else if line < span[0] || line > span[1] {
+			t.Errorf("the %s clef spans y %g to %g, not its line at %g", letter, span[0], span[1], line)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// The statistics window. ::: It reads the session history back (see history.go) and shows it three ways: a heatmap
+// over the Grand Staff, drawn by the game's own drawGrandStaff so every line and space sits exactly where it does in
+// the game; trend charts of accuracy and response time by day; and a table of the weakest pitches.
+
+// accuracyByStaffPosition totals outcomes per line or space of the Grand Staff: F#4 and Fb4 count toward F4.
+func accuracyByStaffPosition(records []roundRecord) map[string]accuracy {
+	return tally(records, func(name string) string {
+		p, err := parsePitch(name)
+		if err != nil {
+			return ""
+		}
+		return p.natural().String()
+	})
+}
+
+// heatColor runs from red (no answers right) through yellow to green (all right), translucent so the staff lines show.
+func heatColor(rate float64) color.Color {
+	if rate < 0.5 {
+		return color.NRGBA{R: 230, G: uint8(460 * rate), B: 40, A: 150}
+	}
+	return color.NRGBA{R: uint8(460 * (1 - rate)), G: 200, B: 40, A: 150}
+}
+
+// drawAccuracyHeatmap lays a band of heat color across each line and space that has any outcomes, labelled at the
+// left with the pitch and its accuracy.
+func drawAccuracyHeatmap(notePositions []NotePosition, totals map[string]accuracy) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	for _, pos := range notePositions {
+		a, ok := totals[pos.Pitch]
+		if !ok {
+			continue
+		}
+		band := canvas.NewRectangle(heatColor(a.rate()))
+		band.Move(fyne.NewPos(grandStaffLeft, pos.Y-15))
+		band.Resize(fyne.NewSize(grandStaffRight-grandStaffLeft, 30))
+		label := canvas.NewText(fmt.Sprintf("%s %.0f%%", pos.Pitch, 100*a.rate()), color.Black)
+		label.TextSize = 16
+		label.Move(fyne.NewPos(10, pos.Y-11))
+		objects = append(objects, band, label)
+	}
+	return objects
+}
+
+// dayStats sums up one day of the history.
+type dayStats struct {
+	Day     string // as 2006-01-02, in local time
+	Pitches accuracy
+	Rounds  int
+	Seconds float64 // over all the day's rounds
+}
+
+// dailyTrend groups the records by day, oldest first.
+func dailyTrend(records []roundRecord) []dayStats {
+	byDay := map[string][]roundRecord{}
+	for _, r := range records {
+		day := r.Time.Local().Format("2006-01-02")
+		byDay[day] = append(byDay[day], r)
+	}
+	var days []dayStats
+	for day, rs := range byDay {
+		d := dayStats{Day: day, Pitches: tally(rs, func(string) string { return day })[day], Rounds: len(rs)}
+		for _, r := range rs {
+			d.Seconds += r.Seconds
+		}
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day < days[j].Day })
+	return days
+}
+
+// drawTrendChart plots values (from 0 up to top) as a line across a w by h chart, with its title, its top value and the
+// first and last of labels along the bottom.
+func drawTrendChart(title string, values []float64, labels []string, top float64, unit string, w, h float32, c color.Color) []fyne.CanvasObject {
+	const left, above = 60, 30
+	grey := color.NRGBA{R: 120, G: 120, B: 120, A: 255}
+	heading := canvas.NewText(title, color.Black)
+	heading.TextStyle = fyne.TextStyle{Bold: true}
+	heading.Move(fyne.NewPos(left, 0))
+	topLabel := canvas.NewText(fmt.Sprintf("%.0f%s", top, unit), grey)
+	topLabel.Move(fyne.NewPos(0, above-8))
+	zero := canvas.NewText("0"+unit, grey)
+	zero.Move(fyne.NewPos(0, above+h-8))
+	objects := []fyne.CanvasObject{heading, topLabel, zero,
+		newStroke(grey, left, above, left, above+h, 1), newStroke(grey, left, above+h, left+w, above+h, 1)}
+	if len(values) == 0 || top <= 0 {
+		return objects
+	}
+	x := func(i int) float32 {
+		if len(values) == 1 {
+			return left + w/2
+		}
+		return left + float32(i)*w/float32(len(values)-1)
+	}
+	y := func(v float64) float32 { return above + h - float32(v/top)*h }
+	for i, v := range values {
+		if i > 0 {
+			objects = append(objects, newStroke(c, x(i-1), y(values[i-1]), x(i), y(v), 3))
+		}
+		dot := canvas.NewCircle(c)
+		dot.Move(fyne.NewPos(x(i)-4, y(v)-4))
+		dot.Resize(fyne.NewSize(8, 8))
+		objects = append(objects, dot)
+	}
+	first := canvas.NewText(labels[0], grey)
+	first.Move(fyne.NewPos(left, above+h+4))
+	objects = append(objects, first)
+	if len(labels) > 1 {
+		last := canvas.NewText(labels[len(labels)-1], grey)
+		last.Alignment = fyne.TextAlignTrailing
+		last.Move(fyne.NewPos(left+w, above+h+4))
+		objects = append(objects, last)
+	}
+	return objects
+}
+
+// showStatsWindow opens the statistics of everything in the history, or of one mode's rounds.
+func showStatsWindow(a fyne.App, history *historyStore) {
+	w := a.NewWindow("Statistics")
+	if history == nil {
+		dialog.ShowInformation("Statistics", "There is no session history to show: it couldn't be opened.", w)
+		w.Resize(fyne.NewSize(400, 200))
+		w.Show()
+		return
+	}
+	all, err := history.load()
+	if err != nil {
+		dialog.ShowError(err, w)
+	}
+	modes := append([]string{"All modes"}, gameModeNames[:]...)
+	filter := widget.NewSelect(modes, nil)
+	summary := widget.NewLabel("")
+	tabs := container.NewAppTabs()
+
+	show := func(mode string) {
+		records := all
+		if mode != "All modes" {
+			records = filterRecords(all, func(r roundRecord) bool { return r.Mode == mode })
+		}
+		summary.SetText(fmt.Sprintf("%d rounds; pitches %s", len(records), tally(records, func(string) string { return "all" })["all"]))
+
+		staff := container.NewWithoutLayout(drawGrandStaff(color.White)...)
+		for _, obj := range drawAccuracyHeatmap(newNotePositions(), accuracyByStaffPosition(records)) {
+			staff.Add(obj)
+		}
+
+		days := dailyTrend(records)
+		var dayNames []string
+		var rates, times []float64
+		slowest := 0.0
+		for _, d := range days {
+			dayNames = append(dayNames, d.Day)
+			rates = append(rates, 100*d.Pitches.rate())
+			times = append(times, d.Seconds/float64(d.Rounds))
+			if t := times[len(times)-1]; t > slowest {
+				slowest = t
+			}
+		}
+		trends := container.NewWithoutLayout(drawTrendChart("Accuracy by day", rates, dayNames, 100, "%", 800, 250, color.NRGBA{R: 30, G: 140, B: 30, A: 255})...)
+		for _, obj := range drawTrendChart("Seconds per round by day", times, dayNames, slowest, "s", 800, 250, color.NRGBA{R: 30, G: 30, B: 200, A: 255}) {
+			obj.Move(obj.Position().Add(fyne.NewPos(0, 340)))
+			trends.Add(obj)
+		}
+
+		byPitch := accuracyByPitch(records)
+		weak := weakest(byPitch, 15)
+		header := []string{"Pitch", "Accuracy", "Right", "Wrong", "Missed"}
+		table := widget.NewTable(
+			func() (int, int) { return len(weak) + 1, len(header) },
+			func() fyne.CanvasObject { return widget.NewLabel("Accuracy 100%") },
+			func(id widget.TableCellID, cell fyne.CanvasObject) {
+				text := ""
+				if id.Row == 0 {
+					text = header[id.Col]
+				} else {
+					p := weak[id.Row-1]
+					acc := byPitch[p]
+					text = []string{p, fmt.Sprintf("%.0f%%", 100*acc.rate()), fmt.Sprint(acc.Right), fmt.Sprint(acc.Wrong), fmt.Sprint(acc.Missed)}[id.Col]
+				}
+				cell.(*widget.Label).SetText(text)
+			})
+
+		selected := tabs.SelectedIndex()
+		tabs.SetItems([]*container.TabItem{
+			container.NewTabItem("Staff heatmap", container.NewScroll(container.NewGridWrap(fyne.NewSize(1000, 820), staff))),
+			container.NewTabItem("Trends", container.NewGridWrap(fyne.NewSize(900, 700), trends)),
+			container.NewTabItem("Weakest pitches", table),
+		})
+		if selected > 0 {
+			tabs.SelectIndex(selected)
+		}
+	}
+	filter.OnChanged = show
+	filter.SetSelected("All modes")
+
+	refresh := widget.NewButton("Reload", func() {
+		if records, err := history.load(); err == nil {
+			all = records
+			show(filter.Selected)
+		} else {
+			dialog.ShowError(err, w)
+		}
+	})
+	w.SetContent(container.NewBorder(container.NewHBox(widget.NewLabel("Show"), filter, refresh, summary), nil, nil, nil, tabs))
+	w.Resize(fyne.NewSize(1050, 950))
+	w.Show()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+)
+
+// Sharps and flats count toward the line or space they sit on; chord tones recorded without an octave have none.
+func TestAccuracyByStaffPosition(t *testing.T) {
+	records := []roundRecord{
+		{Correct: []string{"F#4", "F4"}, Wrong: []string{"Fb4"}, Missing: []string{"C5"}},
+		{Missing: []string{"F#", "A"}},
+	}
+	got := accuracyByStaffPosition(records)
+	if got["F4"] != (accuracy{Right: 2, Wrong: 1}) || got["C5"] != (accuracy{Missed: 1}) || len(got) != 2 {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestHeatColor(t *testing.T) {
+	tests := []struct {
+		rate float64
+		want color.NRGBA
+	}{
+		{0, color.NRGBA{R: 230, G: 0, B: 40, A: 150}},     // red
+		{0.5, color.NRGBA{R: 230, G: 200, B: 40, A: 150}}, // yellow
+		{1, color.NRGBA{R: 0, G: 200, B: 40, A: 150}},     // green
+	}
+	for _, tt := range tests {
+		if got := heatColor(tt.rate); got != tt.want {
+			t.Errorf("heatColor(%g) = %v, want %v", tt.rate, got, tt.want)
+		}
+	}
+}
+
+// Only the lines and spaces with outcomes get a band, across the staff at their own height, colored by accuracy.
+func TestDrawAccuracyHeatmap(t *testing.T) {
+	test.NewTempApp(t) // the labels are text, measured against the app's theme
+	positions := newNotePositions()
+	totals := map[string]accuracy{"E4": {Right: 3, Missed: 1}, "G2": {Wrong: 2}}
+	var bands []*canvas.Rectangle
+	for _, obj := range drawAccuracyHeatmap(positions, totals) {
+		if band, ok := obj.(*canvas.Rectangle); ok {
+			bands = append(bands, band)
+		}
+	}
+	if len(bands) != 2 {
+		t.Fatalf("%d bands, want one for E4 and one for G2", len(bands))
+	}
+	for _, band := range bands {
+		y := band.Position().Y + band.Size().Height/2
+		var pitch string
+		for _, pos := range positions {
+			if pos.Y == y {
+				pitch = pos.Pitch
+			}
+		}
+		a, ok := totals[pitch]
+		if !ok {
+			t.Errorf("a band at y %g, on %q", y, pitch)
+			continue
+		}
+		if band.FillColor != heatColor(a.rate()) || band.Position().X != grandStaffLeft || band.Size().Width != grandStaffRight-grandStaffLeft {
+			t.Errorf("%s: band %v at %v, size %v", pitch, band.FillColor, band.Position(), band.Size())
+		}
+	}
+}
+
+// The history is summed up day by day, in local time, oldest first.
+func TestDailyTrend(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2026, 10, d, hour, 0, 0, 0, time.Local) }
+	records := []roundRecord{
+		{Time: day(19, 9), Correct: []string{"C4"}, Seconds: 4},
+		{Time: day(17, 23), Missing: []string{"D4", "E4"}, Seconds: 10},
+		{Time: day(19, 18), Correct: []string{"G4"}, Wrong: []string{"A4"}, Seconds: 6},
+	}
+	got := dailyTrend(records)
+	want := []dayStats{
+		{Day: "2026-10-17", Pitches: accuracy{Missed: 2}, Rounds: 1, Seconds: 10},
+		{Day: "2026-10-19", Pitches: accuracy{Right: 2, Wrong: 1}, Rounds: 2, Seconds: 10},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("day %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
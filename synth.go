@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+)
+
+// A tiny additive synthesizer. ::: It renders reference tones (saved as WAV files) and the synthesized samples the
+// pitch detector can be checked against offline, so both sides agree on what "A4" sounds like. It also renders the
+// metronome's clicks, which an external player sounds.
+
+const synthSampleRate = 44100
+
+// frequencyOf returns the frequency in Hz of a pitch in the current tuning (A4 = 440 Hz equal temperament by default).
+func frequencyOf(p Pitch) float64 {
+	return currentTuning.frequency(p)
+}
+
+// synthesizeTone renders seconds of a tone at freq: a fundamental plus a few softer harmonics, with short fades at
+// either end so it doesn't click.
+func synthesizeTone(freq, seconds float64, sampleRate int) []float64 {
+	n := int(seconds * float64(sampleRate))
+	samples := make([]float64, n)
+	harmonics := []float64{1, 0.5, 0.25, 0.125} // amplitudes of partials 1..4
+	fade := sampleRate / 100                    // 10 ms
+	for i := range samples {
+		t := float64(i) / float64(sampleRate)
+		var s float64
+		for h, amp := range harmonics {
+			s += amp * math.Sin(2*math.Pi*freq*float64(h+1)*t)
+		}
+		s *= 0.4
+		if i < fade {
+			s *= float64(i) / float64(fade)
+		} else if n-i < fade {
+			s *= float64(n-i) / float64(fade)
+		}
+		samples[i] = s
+	}
+	return samples
+}
+
+// samplesSource serves a slice of samples through the pcmSource interface.
+type samplesSource struct {
+	samples    []float64
+	sampleRate int
+	pos        int
+}
+
+// newSamplesSource wraps already-rendered samples, e.g., from synthesizeTone, as a pcmSource.
+func newSamplesSource(samples []float64, sampleRate int) *samplesSource {
+	return &samplesSource{samples: samples, sampleRate: sampleRate}
+}
+
+// SampleRate implements pcmSource.
+func (s *samplesSource) SampleRate() int { return s.sampleRate }
+
+// ReadFrames implements pcmSource.
+func (s *samplesSource) ReadFrames(buf []float64) (int, error) {
+	if s.pos >= len(s.samples) {
+		return 0, io.EOF
+	}
+	n := copy(buf, s.samples[s.pos:])
+	s.pos += n
+	return n, nil
+}
+
+// soundPlayers are the command-line players a rendered sound can be piped to, as a WAV file on stdin; Fyne has no
+// audio of its own. The first one found on the PATH is used.
+var soundPlayers = [][]string{
+	{"aplay", "-q", "-"},
+	{"paplay"},
+	{"pw-play", "-"},
+	{"ffplay", "-nodisp", "-autoexit", "-loglevel", "quiet", "-"},
+}
+
+// soundPlayer plays short rendered sounds, such as the metronome's clicks, through an external player.
+type soundPlayer struct {
+	command []string // nil when no player was found: play then does nothing
+}
+
+// newSoundPlayer looks for one of soundPlayers.
+func newSoundPlayer() *soundPlayer {
+	for _, command := range soundPlayers {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return &soundPlayer{command: command}
+		}
+	}
+	fmt.Println("No audio player found (aplay, paplay, pw-play or ffplay); the metronome stays silent")
+	return &soundPlayer{}
+}
+
+// play starts samples sounding and returns without waiting for them to finish.
+func (p *soundPlayer) play(samples []float64, sampleRate int) error {
+	if p.command == nil {
+		return nil
+	}
+	var wav bytes.Buffer
+	if err := writeWAV(&wav, samples, sampleRate); err != nil {
+		return err
+	}
+	cmd := exec.Command(p.command[0], p.command[1:]...)
+	cmd.Stdin = &wav
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait() // reaps the player once the sound is over
+	return nil
+}
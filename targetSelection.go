@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Choosing the letter of a find-the-note round. ::: Picking it uniformly keeps a student drilling the notes they
+// already know, so the choice goes through a targetStrategy, and the ones beyond uniform read the session history (see
+// history.go) to favor what the student gets wrong.
+
+// targetStrategy picks the next target from choices (the note letters), given every recorded round, oldest first.
+type targetStrategy interface {
+	name() string
+	usesHistory() bool // whether pick needs the records at all
+	pick(rng *rand.Rand, choices []string, records []roundRecord) string
+}
+
+var targetStrategies = []targetStrategy{uniformTargets{}, weightedTargets{}, leitnerTargets{}}
+
+// currentStrategy is the strategy New Game uses; uniform by default.
+var currentStrategy = targetStrategies[0]
+
+// uniformTargets gives every letter the same chance, as the game always did.
+type uniformTargets struct{}
+
+func (uniformTargets) name() string      { return "Uniform random" }
+func (uniformTargets) usesHistory() bool { return false }
+func (uniformTargets) pick(rng *rand.Rand, choices []string, _ []roundRecord) string {
+	return choices[rng.Intn(len(choices))]
+}
+
+// weightedTargets chooses a letter with a chance in proportion to its error rate over all recorded pitches of that
+// letter, in every mode. A letter never seen yet counts as all wrong, and a letter always right keeps a small chance.
+type weightedTargets struct{}
+
+func (weightedTargets) name() string      { return "Weighted by error rate" }
+func (weightedTargets) usesHistory() bool { return true }
+func (weightedTargets) pick(rng *rand.Rand, choices []string, records []roundRecord) string {
+	byLetter := tally(records, pitchLetter)
+	weights := make([]float64, len(choices))
+	total := 0.0
+	for i, letter := range choices {
+		weights[i] = 1
+		if a, ok := byLetter[letter]; ok {
+			weights[i] = 0.1 + (1 - a.rate())
+		}
+		total += weights[i]
+	}
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return choices[i]
+		}
+		r -= w
+	}
+	return choices[len(choices)-1]
+}
+
+// leitnerTargets is a Leitner-box scheduler. ::: Each letter lives in a box from 1 to leitnerBoxes. Solving a round of
+// it moves it up a box, anything else sends it back to box 1; a letter in box b comes due 2^(b-1) find-the-note rounds
+// after it was last asked. The most overdue letter is picked, and a letter never asked beats them all.
+type leitnerTargets struct{}
+
+const leitnerBoxes = 5
+
+func (leitnerTargets) name() string      { return "Spaced repetition (Leitner boxes)" }
+func (leitnerTargets) usesHistory() bool { return true }
+func (leitnerTargets) pick(rng *rand.Rand, choices []string, records []roundRecord) string {
+	box, last, now := leitnerReplay(records)
+	var due []string
+	best := 0
+	for _, letter := range choices {
+		overdue := now + 1 // never asked
+		if at, ok := last[letter]; ok {
+			overdue = now - at - 1<<(box[letter]-1)
+		}
+		switch {
+		case len(due) == 0 || overdue > best:
+			due, best = []string{letter}, overdue
+		case overdue == best:
+			due = append(due, letter)
+		}
+	}
+	return due[rng.Intn(len(due))]
+}
+
+// pitchLetter is the letter of a recorded pitch, with or without its octave ("F#4", or "F#" for a chord tone), or ""
+// for an entry that doesn't start with one (an empty string in a history file edited by hand, say).
+func pitchLetter(name string) string {
+	if name == "" {
+		return ""
+	}
+	letter := strings.ToUpper(name[:1])
+	if _, ok := letterSemitones[letter]; !ok {
+		return ""
+	}
+	return letter
+}
+
+// leitnerReplay runs the recorded find-the-note rounds through the boxes: the box each letter ends up in, the round
+// number it was last asked in, and how many such rounds there have been.
+func leitnerReplay(records []roundRecord) (box, last map[string]int, now int) {
+	box, last = map[string]int{}, map[string]int{}
+	for _, r := range records {
+		if r.Mode != findTheNote.String() || len(r.Targets) == 0 {
+			continue
+		}
+		letter := pitchLetter(r.Targets[0])
+		if letter == "" {
+			continue
+		}
+		switch {
+		case !r.Solved:
+			box[letter] = 1
+		case box[letter] == 0:
+			box[letter] = 2
+		case box[letter] < leitnerBoxes:
+			box[letter]++
+		}
+		last[letter] = now
+		now++
+	}
+	return box, last, now
+}
+
+// showStrategyDialog picks the strategy New Game uses.
+func showStrategyDialog(parentWindow fyne.Window, onChange func()) {
+	var names []string
+	for _, s := range targetStrategies {
+		names = append(names, s.name())
+	}
+	choice := widget.NewRadioGroup(names, nil)
+	choice.Required = true
+	choice.SetSelected(currentStrategy.name())
+	dialog.ShowCustomConfirm("Target Selection", "Apply", "Cancel", choice, func(ok bool) {
+		if !ok {
+			return
+		}
+		gameMu.Lock()
+		defer gameMu.Unlock()
+		for _, s := range targetStrategies {
+			if s.name() == choice.Selected {
+				currentStrategy = s
+			}
+		}
+		fmt.Printf("Target selection: %s\n", currentStrategy.name())
+		onChange()
+	}, parentWindow)
+}
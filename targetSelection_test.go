@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// A history edited by hand, or cut short, can hold entries that aren't pitches; the strategies skip them.
+func TestStrategiesSkipMalformedPitches(t *testing.T) {
+	records := []roundRecord{
+		{Mode: findTheNote.String(), Targets: []string{""}, Missing: []string{""}},
+		{Mode: findTheNote.String(), Targets: []string{"?4", "C4"}, Wrong: []string{"", "x"}, Missing: []string{"?4"}},
+		{Mode: findTheNote.String(), Targets: []string{"D4"}, Correct: []string{"D4"}, Solved: true},
+		{Mode: buildChord.String(), Targets: []string{"E4", "G4", "B4"}, Missing: []string{"E", "G#"}},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for _, s := range targetStrategies {
+		for i := 0; i < 50; i++ {
+			if got := s.pick(rng, noteLetters, records); pitchLetter(got) != got {
+				t.Fatalf("%s picked %q", s.name(), got)
+			}
+		}
+	}
+	box, last, now := leitnerReplay(records)
+	if now != 1 || box["D"] != 2 || last["D"] != 0 || len(box) != 1 {
+		t.Errorf("leitnerReplay: boxes %v, last asked %v, %d rounds; want only D, in box 2", box, last, now)
+	}
+	if a := tally(records, pitchLetter); a["E"].Missed != 1 || a["G"].Missed != 1 || a["D"].Right != 1 || len(a) != 3 {
+		t.Errorf("tally by letter: %v", a)
+	}
+}
+
+// found is a find-the-note round asking for one pitch, solved or not.
+func found(pitch string, solved bool) roundRecord {
+	r := roundRecord{Mode: findTheNote.String(), Targets: []string{pitch}, Solved: solved}
+	if solved {
+		r.Correct = []string{pitch}
+	} else {
+		r.Missing = []string{pitch}
+	}
+	return r
+}
+
+// With F always missed and B never asked, the weighted strategy picks those two far more often than the letters the
+// student always gets right.
+func TestWeightedTargetsFavorMissedNotes(t *testing.T) {
+	var records []roundRecord
+	for i := 0; i < 5; i++ {
+		for _, letter := range []string{"C", "D", "E", "G", "A"} {
+			records = append(records, found(letter+"4", true))
+		}
+		records = append(records, found("F4", false))
+	}
+	rng := rand.New(rand.NewSource(1))
+	const picks = 2000
+	counts := map[string]int{}
+	for i := 0; i < picks; i++ {
+		counts[weightedTargets{}.pick(rng, noteLetters, records)]++
+	}
+	// The weights are 1.1 for F, 1 for B and 0.1 for each of the other five: 42%, 38% and 4%.
+	for _, letter := range noteLetters {
+		share := float64(counts[letter]) / picks
+		switch letter {
+		case "F", "B":
+			if share < 0.3 {
+				t.Errorf("%s picked %.0f%% of the time; want about 40%%", letter, share*100)
+			}
+		default:
+			if share > 0.1 {
+				t.Errorf("%s picked %.0f%% of the time; want about 4%%", letter, share*100)
+			}
+		}
+	}
+}
+
+// A letter moves up a box for each round of it solved, up to the last box, and back to box 1 for any it isn't.
+func TestLeitnerBoxes(t *testing.T) {
+	tests := []struct {
+		name   string
+		solved []bool
+		box    int
+	}{
+		{"solved once", []bool{true}, 2},
+		{"solved twice", []bool{true, true}, 3},
+		{"solved past the last box", []bool{true, true, true, true, true, true}, leitnerBoxes},
+		{"missed", []bool{false}, 1},
+		{"missed after climbing", []bool{true, true, true, false}, 1},
+		{"solved after a miss", []bool{true, true, false, true}, 2},
+	}
+	for _, tt := range tests {
+		var records []roundRecord
+		for _, s := range tt.solved {
+			records = append(records, found("E4", s))
+		}
+		if box, _, _ := leitnerReplay(records); box["E"] != tt.box {
+			t.Errorf("%s: E is in box %d, want %d", tt.name, box["E"], tt.box)
+		}
+	}
+}
+
+// The most overdue letter comes next: a letter never asked first, then one just missed (box 1, due every round) ahead
+// of one solved three times in a row (box 4, due only every 8 rounds).
+func TestLeitnerTargetsPickTheMostOverdue(t *testing.T) {
+	records := []roundRecord{found("C4", true), found("D4", true), found("C4", true), found("D4", false), found("C4", true)}
+	rng := rand.New(rand.NewSource(1))
+	if got := (leitnerTargets{}).pick(rng, []string{"C", "D", "E"}, records); got != "E" {
+		t.Errorf("picked %s before E, which was never asked", got)
+	}
+	for i := 0; i < 20; i++ {
+		if got := (leitnerTargets{}).pick(rng, []string{"C", "D"}, records); got != "D" {
+			t.Fatalf("picked %s; want the missed D", got)
+		}
+	}
+}
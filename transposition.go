@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Transposing instruments. ::: A B-flat clarinet reads C and sounds B-flat: its music is written a major second above
+// concert pitch. With such an instrument chosen the staff shows written pitch, while everything that sounds or is
+// played (the piano keyboard, a MIDI keyboard, sung answers, saved tones) stays at concert pitch.
+
+// transposingInstrument is how far above concert pitch an instrument's part is written.
+type transposingInstrument struct {
+	Name     string
+	Interval interval // written = concert + Interval
+}
+
+var transposingInstruments = []transposingInstrument{
+	{"Concert pitch (C instruments)", interval{0, 0}},
+	{"Bb clarinet / Bb trumpet", interval{1, 2}},
+	{"Eb alto saxophone", interval{5, 9}},
+	{"F horn / English horn", interval{4, 7}},
+	{"Bb tenor saxophone", interval{8, 14}},
+	{"Eb baritone saxophone", interval{12, 21}},
+}
+
+// currentInstrument is the instrument the staff is written for; concert pitch by default.
+var currentInstrument = transposingInstruments[0]
+
+// transposeDown moves p down by iv, spelling the result on the letter iv.Steps below.
+func (p Pitch) transposeDown(iv interval) Pitch {
+	q := naturalAt(p.step() - iv.Steps)
+	q.Accidental = p.MIDI() - iv.Semitones - q.MIDI()
+	return q
+}
+
+// written is where the instrument's part shows a concert pitch.
+func (t transposingInstrument) written(concert Pitch) Pitch { return concert.transpose(t.Interval) }
+
+// concert is the pitch a written note sounds.
+func (t transposingInstrument) concert(written Pitch) Pitch { return written.transposeDown(t.Interval) }
+
+// fifthsOrder is the line of fifths from F, the order sharps are added in.
+const fifthsOrder = "FCGDAEB"
+
+// fifths places a pitch on the line of fifths: C is 0, G 1, F -1, F# 6, Bb -2. The octave doesn't matter.
+func fifths(p Pitch) int {
+	for i := range fifthsOrder {
+		if fifthsOrder[i:i+1] == p.Letter {
+			return i - 1 + 7*p.Accidental
+		}
+	}
+	return 0
+}
+
+// pitchOnFifths is the inverse of fifths, in octave 4.
+func pitchOnFifths(f int) Pitch {
+	i := ((f+1)%7 + 7) % 7
+	acc := (f + 1 - i) / 7
+	return Pitch{Letter: fifthsOrder[i : i+1], Accidental: acc, Octave: 4}
+}
+
+// keySignature is a major key; its signature has Fifths sharps (or -Fifths flats).
+type keySignature struct {
+	Tonic  Pitch
+	Fifths int
+}
+
+func majorKey(tonic Pitch) keySignature { return keySignature{Tonic: tonic, Fifths: fifths(tonic)} }
+
+func (k keySignature) String() string {
+	switch {
+	case k.Fifths == 0:
+		return fmt.Sprintf("%s major (no sharps or flats)", k.Tonic.name())
+	case k.Fifths == 1:
+		return fmt.Sprintf("%s major (1 sharp)", k.Tonic.name())
+	case k.Fifths == -1:
+		return fmt.Sprintf("%s major (1 flat)", k.Tonic.name())
+	case k.Fifths > 0:
+		return fmt.Sprintf("%s major (%d sharps)", k.Tonic.name(), k.Fifths)
+	}
+	return fmt.Sprintf("%s major (%d flats)", k.Tonic.name(), -k.Fifths)
+}
+
+// transpose moves the key by iv. A key that would need more than six sharps or flats (G# major, with its F double
+// sharp) is respelled as its enharmonic twin (A-flat major), the way a part would actually be written.
+func (k keySignature) transpose(iv interval) keySignature {
+	t := majorKey(k.Tonic.transpose(iv))
+	switch {
+	case t.Fifths > 6:
+		t = majorKey(pitchOnFifths(t.Fifths - 12))
+	case t.Fifths < -6:
+		t = majorKey(pitchOnFifths(t.Fifths + 12))
+	}
+	return t
+}
+
+// accidental is what the key signature does to a letter: +1 if it is sharpened, -1 if flattened. Sharps are added
+// along the line of fifths from F, flats along it backwards from B.
+func (k keySignature) accidental(letter string) int {
+	i := strings.Index(fifthsOrder, letter)
+	switch {
+	case k.Fifths > 0 && i < k.Fifths:
+		return 1
+	case k.Fifths < 0 && 6-i < -k.Fifths:
+		return -1
+	}
+	return 0
+}
+
+// scaleNote is a degree (0 for the tonic) of the key's major scale.
+func (k keySignature) scaleNote(degree int) Pitch {
+	return scale{Tonic: k.Tonic, Kind: majorScale}.notes()[degree%7]
+}
+
+// Where the sharps and flats of a signature sit on the Grand Staff, in the order they are added.
+var (
+	trebleSharps = []string{"F5", "C5", "G5", "D5", "A4", "E5", "B4"}
+	trebleFlats  = []string{"B4", "E5", "A4", "D5", "G4", "C5", "F4"}
+	bassSharps   = []string{"F3", "C3", "G3", "D3", "A2", "E3", "B2"}
+	bassFlats    = []string{"B2", "E3", "A2", "D3", "G2", "C3", "F2"}
+)
+
+// drawKeySignature draws k on both staves of the Grand Staff, starting at x.
+func drawKeySignature(notePositions []NotePosition, k keySignature, x float32, c color.Color) []fyne.CanvasObject {
+	var objects []fyne.CanvasObject
+	treble, bass, sign := trebleSharps, bassSharps, 1
+	count := k.Fifths
+	if count < 0 {
+		treble, bass, sign, count = trebleFlats, bassFlats, -1, -count
+	}
+	for i := 0; i < count; i++ {
+		for _, place := range []string{treble[i], bass[i]} {
+			pos, _ := findNotePosition(notePositions, place)
+			objects = append(objects, drawAccidental(sign, x+float32(i)*22+grandStaffMetrics.HeadW, pos.Y, grandStaffMetrics, c))
+		}
+	}
+	return objects
+}
+
+// transpositionRound is one round of the transposition drill: a note in a key, to be converted either way.
+type transpositionRound struct {
+	ConcertKey keySignature
+	WrittenKey keySignature
+	Concert    Pitch
+	Written    Pitch
+	ToConcert  bool // the written note is shown and the student plays the concert pitch; otherwise the reverse
+}
+
+// randomTranspositionRound picks a concert key of up to five sharps or flats, and a note of its scale that, written
+// for the instrument, lands on the Grand Staff (lowest and highest are the staff's outer notes). The written note is
+// spelled in the written key, so it follows the key's respelling.
+func randomTranspositionRound(rng *rand.Rand, inst transposingInstrument, lowest, highest Pitch) transpositionRound {
+	for {
+		r := transpositionRound{ConcertKey: majorKey(pitchOnFifths(rng.Intn(11) - 5)), ToConcert: rng.Intn(2) == 0}
+		r.WrittenKey = r.ConcertKey.transpose(inst.Interval)
+		degree := rng.Intn(7)
+		r.Concert = r.ConcertKey.scaleNote(degree)
+		r.Concert.Octave += rng.Intn(4) - 2
+		r.Written = r.WrittenKey.scaleNote(degree)
+		r.Written.Octave += (inst.written(r.Concert).MIDI() - r.Written.MIDI()) / 12
+		if r.Written.step() >= lowest.step() && r.Written.step() <= highest.step() {
+			return r
+		}
+	}
+}
+
+// drawTranspositionRound draws what a round shows before it is answered: the written key's signature, starting at x,
+// and when the written note is the question, that note in blue with an accidental only where the signature doesn't
+// cover it. The two come back apart because only a shown note closes the staff to marks; the written direction is
+// answered by placing a note beside the signature.
+func drawTranspositionRound(notePositions []NotePosition, r transpositionRound, x float32) (signature, shown []fyne.CanvasObject) {
+	signature = drawKeySignature(notePositions, r.WrittenKey, x, color.Black)
+	if !r.ToConcert {
+		return signature, nil
+	}
+	pos, _ := findNotePosition(notePositions, r.Written.natural().String())
+	blue := &color.RGBA{R: 0, G: 0, B: 255, A: 255}
+	shown = drawStaffNote(pos, noteXFor(pos.Pitch), blue)
+	if r.Written.Accidental != r.WrittenKey.accidental(r.Written.Letter) {
+		shown = append(shown, drawAccidental(r.Written.Accidental, noteXFor(pos.Pitch), pos.Y, grandStaffMetrics, blue))
+	}
+	return signature, shown
+}
+
+// judgeTransposition checks the notes placed for a written-direction round: one note, spelled as the written key
+// spells it.
+func judgeTransposition(r transpositionRound, inst transposingInstrument, placed []Pitch) (string, bool) {
+	if len(placed) != 1 {
+		return fmt.Sprintf("Place one note: concert %s written for %s", r.Concert, inst.Name), false
+	}
+	switch {
+	case placed[0] == r.Written:
+		return fmt.Sprintf("Correct! Concert %s is written %s in %s", r.Concert, r.Written, r.WrittenKey), true
+	case placed[0].MIDI() == r.Written.MIDI():
+		return fmt.Sprintf("Right key, but in %s it is spelled %s", r.WrittenKey, r.Written), false
+	}
+	return fmt.Sprintf("No: concert %s is written %s, not %s", r.Concert, r.Written, placed[0]), false
+}
+
+// showInstrumentDialog picks the instrument the staff is written for.
+func showInstrumentDialog(parentWindow fyne.Window, onChange func()) {
+	var names []string
+	for _, inst := range transposingInstruments {
+		names = append(names, inst.Name)
+	}
+	choice := widget.NewSelect(names, nil)
+	choice.SetSelected(currentInstrument.Name)
+	dialog.ShowForm("Instrument", "Apply", "Cancel", []*widget.FormItem{widget.NewFormItem("Written for", choice)},
+		func(ok bool) {
+			if ok {
+				gameMu.Lock()
+				defer gameMu.Unlock()
+				currentInstrument = transposingInstruments[choice.SelectedIndex()]
+				fmt.Printf("Instrument: %s\n", currentInstrument.Name)
+				onChange()
+			}
+		}, parentWindow)
+}
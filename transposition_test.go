@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+)
+
+// A written-direction round in D major draws only its key signature, so the staff still takes the student's note, and
+// the note placed is judged by how the written key spells it.
+func TestTranspositionRoundInDMajor(t *testing.T) {
+	test.NewTempApp(t) // the accidentals are text, measured against the app's theme
+	clarinet := transposingInstruments[1]
+	r := transpositionRound{ConcertKey: majorKey(mustParsePitch("C4")), Concert: mustParsePitch("E4")}
+	r.WrittenKey = r.ConcertKey.transpose(clarinet.Interval)
+	r.Written = clarinet.written(r.Concert)
+	if r.WrittenKey != majorKey(mustParsePitch("D4")) || r.Written != mustParsePitch("F#4") {
+		t.Fatalf("concert E4 in C major is written %s in %s for the clarinet", r.Written, r.WrittenKey)
+	}
+
+	signature, shown := drawTranspositionRound(newNotePositions(), r, grandStaffLeft)
+	if len(signature) != 4 || shown != nil { // F# and C#, on both staves
+		t.Fatalf("drew %d signature objects and %d more; want 4 and none", len(signature), len(shown))
+	}
+	tests := []struct {
+		placed []string
+		ok     bool
+		want   string // part of the feedback
+	}{
+		{[]string{"F#4"}, true, "Correct"},
+		{[]string{"Gb4"}, false, "spelled F#4"},
+		{[]string{"F4"}, false, "not F4"},
+		{nil, false, "Place one note"},
+		{[]string{"F#4", "A4"}, false, "Place one note"},
+	}
+	for _, tt := range tests {
+		var placed []Pitch
+		for _, p := range tt.placed {
+			placed = append(placed, mustParsePitch(p))
+		}
+		msg, ok := judgeTransposition(r, clarinet, placed)
+		if ok != tt.ok || !strings.Contains(msg, tt.want) {
+			t.Errorf("%v: got %q, %v; want %q, %v", tt.placed, msg, ok, tt.want, tt.ok)
+		}
+	}
+
+	r.ToConcert = true // the other way round the written note is shown, and the staff is closed to marks
+	if _, shown := drawTranspositionRound(newNotePositions(), r, grandStaffLeft); shown == nil {
+		t.Error("the concert direction shows no note")
+	}
+}
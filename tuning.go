@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Tuning reference and temperament. ::: Every frequency the app computes goes through currentTuning: the synthesizer
+// asks it what a pitch sounds like, and the pitch detector asks it which pitch a frequency is nearest to.
+
+// temperament is a way of dividing the octave into twelve.
+type temperament int
+
+const (
+	equalTemperament temperament = iota // every semitone is 2^(1/12)
+	justIntonation                      // 5-limit just ratios above the key's tonic
+	pythagorean                         // pure 3:2 fifths stacked from the tonic
+	meantone                            // quarter-comma meantone: pure major thirds, narrowed fifths
+)
+
+// temperamentNames are the labels shown in the settings dialog, indexed by temperament.
+var temperamentNames = []string{"Equal", "Just Intonation", "Pythagorean", "Quarter-comma Meantone"}
+
+func (t temperament) String() string { return temperamentNames[t] }
+
+// standardReferences are the A4 pitches offered in the settings: Baroque, "Verdi", modern concert, and many orchestras.
+var standardReferences = []float64{415, 432, 440, 442}
+
+// justRatios and pythagoreanRatios give each semitone above the tonic as a frequency ratio.
+var justRatios = [12]float64{1, 16.0 / 15, 9.0 / 8, 6.0 / 5, 5.0 / 4, 4.0 / 3, 45.0 / 32, 3.0 / 2, 8.0 / 5, 5.0 / 3, 9.0 / 5, 15.0 / 8}
+var pythagoreanRatios = [12]float64{1, 256.0 / 243, 9.0 / 8, 32.0 / 27, 81.0 / 64, 4.0 / 3, 729.0 / 512, 3.0 / 2, 128.0 / 81, 27.0 / 16, 16.0 / 9, 243.0 / 128}
+
+// meantoneFifths places each semitone above the tonic on the chain of fifths (Eb..G# when the tonic is C).
+var meantoneFifths = [12]int{0, 7, 2, -3, 4, -1, 6, 1, 8, 3, -2, 5}
+
+// tuning is a reference pitch plus a temperament; non-equal temperaments are built on Key.
+type tuning struct {
+	A4          float64 // reference frequency of A4, in Hz
+	Temperament temperament
+	Key         int // tonic as semitones above C (0 = C, 7 = G, ...); ignored by equal temperament
+}
+
+// currentTuning is what the synthesizer and the pitch detector use; the Tuning settings dialog changes it.
+var currentTuning = tuning{A4: 440, Temperament: equalTemperament}
+
+// ratio returns the frequency ratio of a note `semitones` above the tonic, octaves included.
+func (t tuning) ratio(semitones int) float64 {
+	octave := int(math.Floor(float64(semitones) / 12))
+	step := semitones - octave*12
+	var r float64
+	switch t.Temperament {
+	case justIntonation:
+		r = justRatios[step]
+	case pythagorean:
+		r = pythagoreanRatios[step]
+	case meantone:
+		fifth := 1200*math.Log2(1.5) - 1200*math.Log2(81.0/80)/4 // a pure fifth narrowed by a quarter of the syntonic comma
+		cents := math.Mod(float64(meantoneFifths[step])*fifth, 1200)
+		if cents < 0 {
+			cents += 1200
+		}
+		r = math.Pow(2, cents/1200)
+	default:
+		r = math.Pow(2, float64(step)/12)
+	}
+	return r * math.Pow(2, float64(octave))
+}
+
+// frequency returns the frequency of p in Hz. A4 always sounds at the reference; the temperament decides the rest.
+func (t tuning) frequency(p Pitch) float64 {
+	if t.Temperament == equalTemperament {
+		return t.A4 * math.Pow(2, float64(p.MIDI()-69)/12)
+	}
+	return t.A4 * t.ratio(p.MIDI()-t.Key) / t.ratio(69-t.Key)
+}
+
+// nearest returns the pitch whose frequency in this tuning is closest to freq, and the difference in cents.
+func (t tuning) nearest(freq float64) (Pitch, float64) {
+	guess := int(math.Round(69 + 12*math.Log2(freq/t.A4)))
+	best, bestCents := guess, math.Inf(1)
+	for midi := guess - 1; midi <= guess+1; midi++ { // no temperament strays a whole semitone from equal
+		cents := 1200 * math.Log2(freq/t.frequency(pitchFromMIDI(midi)))
+		if math.Abs(cents) < math.Abs(bestCents) {
+			best, bestCents = midi, cents
+		}
+	}
+	return pitchFromMIDI(best), bestCents
+}
+
+// showTuningDialog edits currentTuning; onApply runs after a change, holding gameMu.
+func showTuningDialog(parentWindow fyne.Window, onApply func()) {
+	var references []string
+	for _, hz := range standardReferences {
+		references = append(references, strconv.FormatFloat(hz, 'f', -1, 64))
+	}
+	reference := widget.NewSelect(references, nil)
+	reference.SetSelected(strconv.FormatFloat(currentTuning.A4, 'f', -1, 64))
+
+	temper := widget.NewSelect(temperamentNames, nil)
+	temper.SetSelectedIndex(int(currentTuning.Temperament))
+
+	keyNames := []string{"C", "C#", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+	key := widget.NewSelect(keyNames, nil)
+	key.SetSelectedIndex(currentTuning.Key)
+
+	dialog.ShowForm("Tuning", "Apply", "Cancel",
+		[]*widget.FormItem{
+			widget.NewFormItem("A4 (Hz)", reference),
+			widget.NewFormItem("Temperament", temper),
+			widget.NewFormItem("Key", key),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			hz, err := strconv.ParseFloat(reference.Selected, 64)
+			if err != nil {
+				dialog.ShowError(err, parentWindow)
+				return
+			}
+			gameMu.Lock()
+			defer gameMu.Unlock()
+			currentTuning = tuning{A4: hz, Temperament: temperament(temper.SelectedIndex()), Key: key.SelectedIndex()}
+			fmt.Printf("Tuning: A4 = %g Hz, %s in %s\n", hz, currentTuning.Temperament, keyNames[currentTuning.Key])
+			onApply()
+		}, parentWindow)
+}
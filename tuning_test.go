@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTuningFrequency(t *testing.T) {
+	tests := []struct {
+		name   string
+		tuning tuning
+		pitch  string
+		want   float64
+	}{
+		{"A4 is the reference", tuning{A4: 440}, "A4", 440},
+		{"Baroque A4", tuning{A4: 415}, "A4", 415},
+		{"an octave up", tuning{A4: 432}, "A5", 864},
+		{"equal middle C", tuning{A4: 440}, "C4", 261.6256},
+		{"equal middle C at 442", tuning{A4: 442}, "C4", 262.8148},
+		{"A4 holds in just intonation", tuning{A4: 440, Temperament: justIntonation}, "A4", 440},
+		{"A4 holds in meantone on G", tuning{A4: 440, Temperament: meantone, Key: 7}, "A4", 440},
+	}
+	for _, tt := range tests {
+		if got := tt.tuning.frequency(mustParsePitch(tt.pitch)); math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("%s: %s = %.4f Hz, want %.4f", tt.name, tt.pitch, got, tt.want)
+		}
+	}
+}
+
+// The intervals each temperament tunes pure, measured above the key's tonic.
+func TestTemperamentIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		tuning    tuning
+		low, high string
+		ratio     float64
+	}{
+		{"just major third", tuning{A4: 440, Temperament: justIntonation}, "C4", "E4", 5.0 / 4},
+		{"just fifth", tuning{A4: 440, Temperament: justIntonation}, "C4", "G4", 3.0 / 2},
+		{"just major sixth", tuning{A4: 440, Temperament: justIntonation}, "C4", "A4", 5.0 / 3},
+		{"just third in D", tuning{A4: 440, Temperament: justIntonation, Key: 2}, "D4", "F#4", 5.0 / 4},
+		{"Pythagorean fifth", tuning{A4: 440, Temperament: pythagorean}, "C4", "G4", 3.0 / 2},
+		{"Pythagorean ditone", tuning{A4: 440, Temperament: pythagorean}, "C4", "E4", 81.0 / 64},
+		{"meantone major third", tuning{A4: 440, Temperament: meantone}, "C4", "E4", 5.0 / 4},
+		{"meantone fifth, a quarter comma narrow", tuning{A4: 440, Temperament: meantone}, "C4", "G4", math.Pow(5, 0.25)},
+		{"equal fifth", tuning{A4: 440}, "C4", "G4", math.Pow(2, 7.0/12)},
+		{"octaves are pure in every temperament", tuning{A4: 440, Temperament: meantone, Key: 5}, "F3", "F4", 2},
+	}
+	for _, tt := range tests {
+		got := tt.tuning.frequency(mustParsePitch(tt.high)) / tt.tuning.frequency(mustParsePitch(tt.low))
+		if math.Abs(got-tt.ratio) > 1e-9 {
+			t.Errorf("%s: %s-%s is %.6f, want %.6f", tt.name, tt.low, tt.high, got, tt.ratio)
+		}
+	}
+}
+
+// Every staff pitch is heard back as itself, in every temperament, key and reference.
+func TestTuningNearestRoundTrip(t *testing.T) {
+	for _, ref := range standardReferences {
+		for temper := range temperamentNames {
+			for key := 0; key < 12; key++ {
+				tu := tuning{A4: ref, Temperament: temperament(temper), Key: key}
+				for midi := mustParsePitch("E1").MIDI(); midi <= mustParsePitch("C7").MIDI(); midi++ {
+					p := pitchFromMIDI(midi)
+					got, cents := tu.nearest(tu.frequency(p))
+					if got.MIDI() != midi || math.Abs(cents) > 1e-6 {
+						t.Fatalf("%s, A4 = %g, key %d: %s came back as %s, %+.3f cents", tu.Temperament, ref, key, p, got, cents)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// pcmSource is anything that yields mono PCM samples in the range -1..1. ::: The pitch detector only ever sees this
+// interface, so a WAV file, a synthesized tone, or (one day) a live microphone are all the same to it.
+type pcmSource interface {
+	SampleRate() int
+	ReadFrames(buf []float64) (int, error) // fills buf; returns io.EOF once the source is used up
+}
+
+// wavReader streams the samples of a RIFF/WAVE file, mixing multi-channel audio down to mono.
+type wavReader struct {
+	r             *bufio.Reader
+	sampleRate    int
+	channels      int
+	bitsPerSample int
+	float         bool  // IEEE float samples rather than integer PCM
+	remaining     int64 // bytes left in the data chunk
+}
+
+// newWAVReader reads the header of a WAV file and leaves r positioned at the first sample.
+// Integer PCM (8, 16, 24, 32 bit) and 32-bit float are supported, including WAVE_FORMAT_EXTENSIBLE headers.
+func newWAVReader(r io.Reader) (*wavReader, error) {
+	br := bufio.NewReader(r)
+	var riff [12]byte
+	if _, err := io.ReadFull(br, riff[:]); err != nil {
+		return nil, fmt.Errorf("reading WAV header: %w", err)
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, errors.New("not a WAV file")
+	}
+
+	w := &wavReader{r: br}
+	haveFormat := false
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return nil, fmt.Errorf("WAV file has no data chunk: %w", err)
+		}
+		id, size := string(header[0:4]), int64(binary.LittleEndian.Uint32(header[4:]))
+		switch id {
+		case "fmt ":
+			body, err := io.ReadAll(io.LimitReader(br, size+size%2)) // chunks are padded to an even length
+			if err != nil {
+				return nil, err
+			}
+			if int64(len(body)) < size+size%2 { // the size is only trusted as far as the file goes
+				return nil, fmt.Errorf("WAV format chunk is cut short: %w", io.ErrUnexpectedEOF)
+			}
+			if size < 16 {
+				return nil, errors.New("WAV format chunk is too short")
+			}
+			format := binary.LittleEndian.Uint16(body[0:])
+			w.channels = int(binary.LittleEndian.Uint16(body[2:]))
+			w.sampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+			w.bitsPerSample = int(binary.LittleEndian.Uint16(body[14:]))
+			if format == 0xFFFE && size >= 26 { // WAVE_FORMAT_EXTENSIBLE: the real format is the first two bytes of the sub-format GUID
+				format = binary.LittleEndian.Uint16(body[24:])
+			}
+			switch {
+			case format == 1 && (w.bitsPerSample == 8 || w.bitsPerSample == 16 || w.bitsPerSample == 24 || w.bitsPerSample == 32):
+			case format == 3 && w.bitsPerSample == 32:
+				w.float = true
+			default:
+				return nil, fmt.Errorf("unsupported WAV encoding (format %d, %d bits)", format, w.bitsPerSample)
+			}
+			if w.channels < 1 || w.sampleRate < 1 {
+				return nil, errors.New("WAV format chunk is invalid")
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, errors.New("WAV data chunk comes before its format chunk")
+			}
+			w.remaining = size
+			return w, nil
+		default:
+			if _, err := io.CopyN(io.Discard, br, size+size%2); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// SampleRate implements pcmSource.
+func (w *wavReader) SampleRate() int { return w.sampleRate }
+
+// ReadFrames implements pcmSource: one float per frame, averaged across channels.
+func (w *wavReader) ReadFrames(buf []float64) (int, error) {
+	bytesPerSample := w.bitsPerSample / 8
+	frameSize := bytesPerSample * w.channels
+	frame := make([]byte, frameSize)
+	n := 0
+	for n < len(buf) {
+		if w.remaining < int64(frameSize) {
+			break
+		}
+		if _, err := io.ReadFull(w.r, frame); err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		}
+		w.remaining -= int64(frameSize)
+		var sum float64
+		for ch := 0; ch < w.channels; ch++ {
+			sum += w.decodeSample(frame[ch*bytesPerSample:])
+		}
+		buf[n] = sum / float64(w.channels)
+		n++
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// decodeSample converts one little-endian sample to -1..1.
+func (w *wavReader) decodeSample(b []byte) float64 {
+	switch {
+	case w.float:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case w.bitsPerSample == 8: // 8-bit WAV is unsigned
+		return (float64(b[0]) - 128) / 128
+	case w.bitsPerSample == 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case w.bitsPerSample == 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8 // sign-extend via the top byte
+		return float64(v) / 8388608
+	default:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+	}
+}
+
+// writeWAV writes mono samples (-1..1) as a 16-bit PCM WAV file.
+func writeWAV(w io.Writer, samples []float64, sampleRate int) error {
+	dataSize := uint32(len(samples) * 2)
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 36+dataSize)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // integer PCM
+	binary.LittleEndian.PutUint16(header[22:], 1) // mono
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*2))
+	binary.LittleEndian.PutUint16(header[32:], 2)
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], dataSize)
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	var sample [2]byte
+	for _, s := range samples {
+		s = math.Max(-1, math.Min(1, s))
+		binary.LittleEndian.PutUint16(sample[:], uint16(int16(s*32767)))
+		if _, err := bw.Write(sample[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}